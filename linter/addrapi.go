@@ -0,0 +1,328 @@
+package linter
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"net/netip"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// noArg marks an AddrAPI argument the function does not take
+const noArg = -1
+
+// ArgKind is how an AddrAPI argument spells the address
+type ArgKind int
+
+const (
+	// ArgHostPort is a "host:port" string, e.g. "127.0.0.1:8080"
+	ArgHostPort ArgKind = iota
+	// ArgIPAddr is a *net.TCPAddr or *net.UDPAddr
+	ArgIPAddr
+	// ArgURL is a URL string, e.g. "http://127.0.0.1:8080/"
+	ArgURL
+	// ArgTarget is a grpc style target, e.g. "dns:///127.0.0.1:50051"
+	ArgTarget
+)
+
+// AddrAPI describes a function or method taking a network address.
+//
+// Append to ListenAPIs (or DialAPIs) to teach ip6check about other packages
+type AddrAPI struct {
+	// Pkg is the import path, e.g. "net"
+	Pkg string
+	// Recv is the receiver type name for methods, e.g. "ListenConfig".
+	// Empty for package functions
+	Recv string
+	// Name of the function or method
+	Name string
+	// Network is the index of the network argument ("tcp"), or noArg
+	Network int
+	// Arg is the index of the address argument
+	Arg int
+	// Kind of the address argument
+	Kind ArgKind
+}
+
+// AddrField describes a struct field holding a "host:port" address
+type AddrField struct {
+	// Pkg is the import path, e.g. "net/http"
+	Pkg string
+	// Type is the struct type name, e.g. "Server"
+	Type string
+	// Field name, e.g. "Addr"
+	Field string
+}
+
+// ListenAPIs are the listen entry points checked by AnalyzerIP4
+var ListenAPIs = []AddrAPI{
+	{Pkg: "net", Name: "Listen", Network: 0, Arg: 1},
+	{Pkg: "net", Name: "ListenPacket", Network: 0, Arg: 1},
+	{Pkg: "net", Name: "ListenTCP", Network: 0, Arg: 1, Kind: ArgIPAddr},
+	{Pkg: "net", Name: "ListenUDP", Network: 0, Arg: 1, Kind: ArgIPAddr},
+	{Pkg: "net", Recv: "ListenConfig", Name: "Listen", Network: 1, Arg: 2},
+	{Pkg: "net", Recv: "ListenConfig", Name: "ListenPacket", Network: 1, Arg: 2},
+	{Pkg: "crypto/tls", Name: "Listen", Network: 0, Arg: 1},
+	{Pkg: "net/http", Name: "ListenAndServe", Network: noArg, Arg: 0},
+	{Pkg: "net/http", Name: "ListenAndServeTLS", Network: noArg, Arg: 0},
+}
+
+// ListenFields are the struct fields checked by AnalyzerIP4
+var ListenFields = []AddrField{
+	{Pkg: "net/http", Type: "Server", Field: "Addr"},
+}
+
+// forEachListenAddr calls onCall for each call of ListenAPIs and onField
+// for each "host:port" assigned to ListenFields, in composite literals
+// (http.Server{Addr: ...}) and assignments (srv.Addr = ...)
+func forEachListenAddr(pass *analysis.Pass, inspect *inspector.Inspector, onCall func(api *AddrAPI, call *ast.CallExpr), onField func(addr ast.Expr)) {
+	nodeFilter := []ast.Node{
+		(*ast.CallExpr)(nil),
+		(*ast.CompositeLit)(nil),
+		(*ast.AssignStmt)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(node ast.Node) {
+		switch node := node.(type) {
+		case *ast.CallExpr:
+			if api := matchAPI(typeutil.StaticCallee(pass.TypesInfo, node), ListenAPIs); api != nil {
+				onCall(api, node)
+			}
+		case *ast.CompositeLit:
+			t := pass.TypesInfo.TypeOf(node)
+			for _, elt := range node.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					if key, ok := kv.Key.(*ast.Ident); ok && matchField(t, key.Name, ListenFields) != nil {
+						onField(kv.Value)
+					}
+				}
+			}
+		case *ast.AssignStmt:
+			if len(node.Lhs) != len(node.Rhs) {
+				return
+			}
+			for i, lhs := range node.Lhs {
+				sel, ok := lhs.(*ast.SelectorExpr)
+				if !ok {
+					continue
+				}
+				selection := pass.TypesInfo.Selections[sel]
+				if selection != nil && selection.Kind() == types.FieldVal &&
+					matchField(selection.Recv(), sel.Sel.Name, ListenFields) != nil {
+					onField(node.Rhs[i])
+				}
+			}
+		}
+	})
+}
+
+// matchAPI returns the entry of apis for the function fn, or nil
+func matchAPI(fn *types.Func, apis []AddrAPI) *AddrAPI {
+	if fn == nil || fn.Pkg() == nil {
+		return nil
+	}
+	recv := ""
+	if r := fn.Type().(*types.Signature).Recv(); r != nil {
+		named, ok := derefType(r.Type()).(*types.Named)
+		if !ok {
+			return nil
+		}
+		recv = named.Obj().Name()
+	}
+	for i := range apis {
+		api := &apis[i]
+		if api.Pkg == fn.Pkg().Path() && api.Recv == recv && api.Name == fn.Name() {
+			return api
+		}
+	}
+	return nil
+}
+
+// matchField returns the entry of fields for field of the struct type t
+func matchField(t types.Type, field string, fields []AddrField) *AddrField {
+	for i := range fields {
+		f := &fields[i]
+		if f.Field == field && isNamedType(derefType(t), f.Pkg, f.Type) {
+			return f
+		}
+	}
+	return nil
+}
+
+// derefType strips one pointer
+func derefType(t types.Type) types.Type {
+	if p, ok := t.(*types.Pointer); ok {
+		return p.Elem()
+	}
+	return t
+}
+
+// isIPNetwork reports whether network may be tcp, udp or ip.  Unknown
+// networks count as ip
+func isIPNetwork(network strValue) bool {
+	return !network.complete || !strings.HasPrefix(network.prefix, "unix")
+}
+
+// apiHost returns the host passed to a call of api, e.g. "127.0.0.1" for
+// net.Listen("tcp", "127.0.0.1:8080").  Calls on unix sockets are skipped
+func apiHost(eval *strEvaluator, api *AddrAPI, call *ast.CallExpr) (string, ast.Expr, bool) {
+	arg := func(i int) ast.Expr {
+		if i == noArg || i >= len(call.Args) {
+			return nil
+		}
+		return call.Args[i]
+	}
+	// Unknown networks are checked
+	if network := arg(api.Network); network != nil && !isIPNetwork(eval.eval(network)) {
+		return "", nil, false
+	}
+	addr := arg(api.Arg)
+	if addr == nil {
+		return "", nil, false
+	}
+	var (
+		host string
+		ok   bool
+	)
+	switch api.Kind {
+	case ArgHostPort:
+		// traced through constants, variables, concatenation and fmt.Sprintf
+		host, _, ok = eval.eval(addr).hostPort()
+	case ArgIPAddr:
+		// &net.TCPAddr{IP: ...}
+		var ip netip.Addr
+		ip, _, ok = eval.evalIPAddr(addr)
+		host = ip.String()
+	case ArgURL:
+		host, ok = eval.eval(addr).urlHost()
+	case ArgTarget:
+		host, ok = eval.eval(addr).targetHost()
+	}
+	return host, addr, ok
+}
+
+// resolve follows parentheses and variables assigned exactly once to the
+// expression that defines them
+func (e *strEvaluator) resolve(expr ast.Expr) ast.Expr {
+	for depth := 0; depth < maxEvalDepth && expr != nil; depth++ {
+		expr = ast.Unparen(expr)
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			return expr
+		}
+		v, ok := e.pass.TypesInfo.ObjectOf(ident).(*types.Var)
+		if !ok || (v.Exported() && v.Parent() == v.Pkg().Scope()) {
+			return expr
+		}
+		def, ok := e.defs[v]
+		if !ok || def == nil {
+			return expr
+		}
+		expr = def
+	}
+	return expr
+}
+
+// evalIPAddr returns the IP of a *net.TCPAddr or *net.UDPAddr expression,
+// e.g. &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}.  A missing IP is the
+// dual-stack wildcard and is not returned
+func (e *strEvaluator) evalIPAddr(expr ast.Expr) (netip.Addr, ast.Expr, bool) {
+	expr = e.resolve(expr)
+	if u, ok := expr.(*ast.UnaryExpr); ok {
+		expr = e.resolve(u.X)
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return netip.Addr{}, nil, false
+	}
+	t := e.pass.TypesInfo.TypeOf(lit)
+	if !isNamedType(t, "net", "TCPAddr") && !isNamedType(t, "net", "UDPAddr") {
+		return netip.Addr{}, nil, false
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "IP" {
+			addr, ok := e.evalIP(kv.Value)
+			return addr, kv.Value, ok
+		}
+	}
+	return netip.Addr{}, nil, false
+}
+
+// evalIP returns the value of a net.IP expression built from net.IPv4,
+// net.ParseIP, a net.IP{...} literal or one of the net.IPv4* variables
+func (e *strEvaluator) evalIP(expr ast.Expr) (netip.Addr, bool) {
+	expr = e.resolve(expr)
+	switch expr := expr.(type) {
+	case *ast.CallExpr:
+		fn, _ := e.pass.TypesInfo.Uses[calleeIdent(expr.Fun)].(*types.Func)
+		switch {
+		case isPkgFunc(fn, "net", "IPv4") && len(expr.Args) == 4:
+			var b [4]byte
+			for i, arg := range expr.Args {
+				v, ok := e.constInt(arg)
+				if !ok {
+					return netip.Addr{}, false
+				}
+				b[i] = byte(v)
+			}
+			return netip.AddrFrom4(b), true
+		case isPkgFunc(fn, "net", "ParseIP") && len(expr.Args) == 1:
+			s := e.eval(expr.Args[0])
+			if !s.complete {
+				return netip.Addr{}, false
+			}
+			addr, err := netip.ParseAddr(s.prefix)
+			return addr, err == nil
+		}
+	case *ast.CompositeLit:
+		if !isNamedType(e.pass.TypesInfo.TypeOf(expr), "net", "IP") {
+			return netip.Addr{}, false
+		}
+		b := make([]byte, 0, len(expr.Elts))
+		for _, elt := range expr.Elts {
+			v, ok := e.constInt(elt)
+			if !ok {
+				return netip.Addr{}, false
+			}
+			b = append(b, byte(v))
+		}
+		return netip.AddrFromSlice(b)
+	case *ast.SelectorExpr, *ast.Ident:
+		obj := e.pass.TypesInfo.Uses[calleeIdent(expr)]
+		switch {
+		case isPkgObject(obj, "net", "IPv4zero"):
+			return netip.IPv4Unspecified(), true
+		case isPkgObject(obj, "net", "IPv4bcast"):
+			return netip.AddrFrom4([4]byte{255, 255, 255, 255}), true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// constInt returns the value of an integer constant expression
+func (e *strEvaluator) constInt(expr ast.Expr) (int64, bool) {
+	tv, ok := e.pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil {
+		return 0, false
+	}
+	return constant.Int64Val(constant.ToInt(tv.Value))
+}
+
+// calleeIdent returns the identifier naming a (possibly package qualified)
+// function or variable
+func calleeIdent(expr ast.Expr) *ast.Ident {
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		return expr
+	case *ast.SelectorExpr:
+		return expr.Sel
+	}
+	return nil
+}
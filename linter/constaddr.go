@@ -0,0 +1,275 @@
+package linter
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"net"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// maxEvalDepth bounds how far strEvaluator follows variables
+const maxEvalDepth = 16
+
+// strValue is the statically known part of a string expression.  When
+// complete is false only the prefix is known, e.g. "127.0.0.1:" for
+// host + ":" + port with an unknown port
+type strValue struct {
+	prefix   string
+	complete bool
+}
+
+func (v strValue) concat(w strValue) strValue {
+	if !v.complete {
+		return v
+	}
+	return strValue{prefix: v.prefix + w.prefix, complete: w.complete}
+}
+
+// hostPort splits the known value into host and port.  A prefix ending in
+// ":" (host + ":" + port) still has a known host
+func (v strValue) hostPort() (host, port string, ok bool) {
+	s := v.prefix
+	if !v.complete {
+		if !strings.HasSuffix(s, ":") {
+			return "", "", false
+		}
+		s += "0"
+	}
+	host, port, err := net.SplitHostPort(s)
+	return host, port, err == nil
+}
+
+// urlHost returns the host of a URL, e.g. "127.0.0.1" for
+// "http://127.0.0.1:8080/path"
+func (v strValue) urlHost() (string, bool) {
+	_, rest, ok := strings.Cut(v.prefix, "://")
+	if !ok {
+		return "", false
+	}
+	authority := strValue{prefix: rest, complete: v.complete}
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		authority = strValue{prefix: rest[:i], complete: true}
+	}
+	if i := strings.LastIndexByte(authority.prefix, '@'); i >= 0 {
+		authority.prefix = authority.prefix[i+1:]
+	}
+	if host, _, ok := authority.hostPort(); ok {
+		return host, true
+	}
+	// no port
+	if !authority.complete || strings.Contains(authority.prefix, ":") && !strings.HasPrefix(authority.prefix, "[") {
+		return "", false
+	}
+	return strings.Trim(authority.prefix, "[]"), authority.prefix != ""
+}
+
+// targetHost returns the host of a grpc style target: "host:port",
+// "dns:///host:port", "passthrough:///host:port" or "ipv4:host:port"
+func (v strValue) targetHost() (string, bool) {
+	s := v.prefix
+	if scheme, rest, ok := strings.Cut(s, "://"); ok && !strings.Contains(scheme, ":") {
+		// drop the authority, the endpoint is the path
+		_, endpoint, ok := strings.Cut(rest, "/")
+		if !ok {
+			return "", false
+		}
+		s = endpoint
+	} else if rest, ok := strings.CutPrefix(s, "ipv4:"); ok {
+		s, _, _ = strings.Cut(rest, ",")
+	} else if strings.HasPrefix(s, "unix:") {
+		return "", false
+	}
+	host, _, ok := strValue{prefix: s, complete: v.complete}.hostPort()
+	return host, ok
+}
+
+// strEvaluator traces string expressions through constants, concatenation,
+// fmt.Sprintf and local variables that are assigned exactly once
+type strEvaluator struct {
+	pass *analysis.Pass
+	// defs holds the single assigned value of a variable, or nil when it is
+	// assigned more than once, its address is taken or one of its fields or
+	// elements is assigned
+	defs map[*types.Var]ast.Expr
+}
+
+func newStrEvaluator(pass *analysis.Pass, inspect *inspector.Inspector) *strEvaluator {
+	e := &strEvaluator{pass: pass, defs: make(map[*types.Var]ast.Expr)}
+	nodeFilter := []ast.Node{
+		(*ast.AssignStmt)(nil),
+		(*ast.ValueSpec)(nil),
+		(*ast.RangeStmt)(nil),
+		(*ast.UnaryExpr)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				var rhs ast.Expr
+				if n.Tok == token.DEFINE && len(n.Lhs) == len(n.Rhs) {
+					if ident, ok := lhs.(*ast.Ident); ok && pass.TypesInfo.Defs[ident] != nil {
+						rhs = n.Rhs[i]
+					}
+				}
+				e.define(lhs, rhs)
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				var rhs ast.Expr
+				if len(n.Values) == len(n.Names) {
+					rhs = n.Values[i]
+				}
+				e.define(name, rhs)
+			}
+		case *ast.RangeStmt:
+			if n.Key != nil {
+				e.define(n.Key, nil)
+			}
+			if n.Value != nil {
+				e.define(n.Value, nil)
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				e.define(n.X, nil)
+			}
+		}
+	})
+	return e
+}
+
+// define records rhs as the value of the variable lhs.  A second definition
+// (or a nil rhs) makes the variable unknown, as does assigning to or taking
+// the address of a part of it, e.g. addr.IP = ip or &addr.IP
+func (e *strEvaluator) define(lhs ast.Expr, rhs ast.Expr) {
+	ident, ok := ast.Unparen(lhs).(*ast.Ident)
+	if !ok {
+		if ident = e.rootIdent(lhs); ident == nil {
+			return
+		}
+		rhs = nil
+	}
+	v, ok := e.pass.TypesInfo.ObjectOf(ident).(*types.Var)
+	if !ok {
+		return
+	}
+	if _, seen := e.defs[v]; seen {
+		rhs = nil
+	}
+	e.defs[v] = rhs
+}
+
+// rootIdent returns the variable x of x.f, x[i], *x and combinations, or
+// nil
+func (e *strEvaluator) rootIdent(expr ast.Expr) *ast.Ident {
+	for {
+		switch x := ast.Unparen(expr).(type) {
+		case *ast.Ident:
+			return x
+		case *ast.SelectorExpr:
+			// pkg.Var is a variable of its own, not a part of pkg
+			if _, ok := e.pass.TypesInfo.Selections[x]; !ok {
+				return nil
+			}
+			expr = x.X
+		case *ast.IndexExpr:
+			expr = x.X
+		case *ast.StarExpr:
+			expr = x.X
+		default:
+			return nil
+		}
+	}
+}
+
+// eval returns the statically known part of the string expression expr
+func (e *strEvaluator) eval(expr ast.Expr) strValue {
+	return e.evalDepth(expr, 0)
+}
+
+func (e *strEvaluator) evalDepth(expr ast.Expr, depth int) strValue {
+	if depth > maxEvalDepth || expr == nil {
+		return strValue{}
+	}
+	expr = ast.Unparen(expr)
+	// constants, including concatenations of constants
+	if tv, ok := e.pass.TypesInfo.Types[expr]; ok && tv.Value != nil {
+		if tv.Value.Kind() == constant.String {
+			return strValue{prefix: constant.StringVal(tv.Value), complete: true}
+		}
+		return strValue{prefix: tv.Value.ExactString(), complete: true}
+	}
+	switch expr := expr.(type) {
+	case *ast.BinaryExpr:
+		if expr.Op == token.ADD {
+			return e.evalDepth(expr.X, depth+1).concat(e.evalDepth(expr.Y, depth+1))
+		}
+	case *ast.Ident:
+		v, ok := e.pass.TypesInfo.ObjectOf(expr).(*types.Var)
+		if !ok || (v.Exported() && v.Parent() == v.Pkg().Scope()) {
+			// exported package variables may be changed by other packages
+			return strValue{}
+		}
+		return e.evalDepth(e.defs[v], depth+1)
+	case *ast.CallExpr:
+		fn := typeutil.StaticCallee(e.pass.TypesInfo, expr)
+		if isPkgFunc(fn, "fmt", "Sprintf") && len(expr.Args) > 0 {
+			return e.sprintf(expr, depth+1)
+		}
+		if isPkgFunc(fn, "net", "JoinHostPort") && len(expr.Args) == 2 {
+			host := e.evalDepth(expr.Args[0], depth+1)
+			if !host.complete {
+				return strValue{}
+			}
+			if strings.Contains(host.prefix, ":") {
+				host.prefix = "[" + host.prefix + "]"
+			}
+			return host.concat(strValue{prefix: ":", complete: true}).concat(e.evalDepth(expr.Args[1], depth+1))
+		}
+	}
+	return strValue{}
+}
+
+// sprintf evaluates fmt.Sprintf for the %s, %d and %v verbs.  Formatting
+// stops at the first unknown argument or unsupported verb
+func (e *strEvaluator) sprintf(call *ast.CallExpr, depth int) strValue {
+	format := e.evalDepth(call.Args[0], depth)
+	if !format.complete {
+		return strValue{}
+	}
+	args := call.Args[1:]
+	var out strings.Builder
+	f := format.prefix
+	for len(f) > 0 {
+		i := strings.IndexByte(f, '%')
+		if i < 0 {
+			out.WriteString(f)
+			break
+		}
+		out.WriteString(f[:i])
+		if i+1 >= len(f) {
+			return strValue{prefix: out.String()}
+		}
+		verb := f[i+1]
+		f = f[i+2:]
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+		if len(args) == 0 || (verb != 's' && verb != 'd' && verb != 'v') {
+			return strValue{prefix: out.String()}
+		}
+		arg := e.evalDepth(args[0], depth)
+		args = args[1:]
+		out.WriteString(arg.prefix)
+		if !arg.complete {
+			return strValue{prefix: out.String()}
+		}
+	}
+	return strValue{prefix: out.String(), complete: true}
+}
@@ -0,0 +1,71 @@
+package linter
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// DialAPIs are the outbound entry points checked by AnalyzerDial
+var DialAPIs = []AddrAPI{
+	{Pkg: "net", Name: "Dial", Network: 0, Arg: 1},
+	{Pkg: "net", Name: "DialTimeout", Network: 0, Arg: 1},
+	{Pkg: "net", Name: "DialTCP", Network: 0, Arg: 2, Kind: ArgIPAddr},
+	{Pkg: "net", Name: "DialUDP", Network: 0, Arg: 2, Kind: ArgIPAddr},
+	{Pkg: "net", Recv: "Dialer", Name: "Dial", Network: 0, Arg: 1},
+	{Pkg: "net", Recv: "Dialer", Name: "DialContext", Network: 1, Arg: 2},
+	{Pkg: "crypto/tls", Name: "Dial", Network: 0, Arg: 1},
+	{Pkg: "crypto/tls", Name: "DialWithDialer", Network: 1, Arg: 2},
+	{Pkg: "crypto/tls", Recv: "Dialer", Name: "Dial", Network: 0, Arg: 1},
+	{Pkg: "crypto/tls", Recv: "Dialer", Name: "DialContext", Network: 1, Arg: 2},
+	{Pkg: "net/http", Name: "Get", Network: noArg, Arg: 0, Kind: ArgURL},
+	{Pkg: "net/http", Name: "Head", Network: noArg, Arg: 0, Kind: ArgURL},
+	{Pkg: "net/http", Name: "Post", Network: noArg, Arg: 0, Kind: ArgURL},
+	{Pkg: "net/http", Name: "PostForm", Network: noArg, Arg: 0, Kind: ArgURL},
+	{Pkg: "net/http", Recv: "Client", Name: "Get", Network: noArg, Arg: 0, Kind: ArgURL},
+	{Pkg: "net/http", Recv: "Client", Name: "Head", Network: noArg, Arg: 0, Kind: ArgURL},
+	{Pkg: "net/http", Recv: "Client", Name: "Post", Network: noArg, Arg: 0, Kind: ArgURL},
+	{Pkg: "net/http", Recv: "Client", Name: "PostForm", Network: noArg, Arg: 0, Kind: ArgURL},
+	{Pkg: "net/http", Name: "NewRequest", Network: noArg, Arg: 1, Kind: ArgURL},
+	{Pkg: "net/http", Name: "NewRequestWithContext", Network: noArg, Arg: 2, Kind: ArgURL},
+	{Pkg: "google.golang.org/grpc", Name: "Dial", Network: noArg, Arg: 0, Kind: ArgTarget},
+	{Pkg: "google.golang.org/grpc", Name: "DialContext", Network: noArg, Arg: 1, Kind: ArgTarget},
+	{Pkg: "google.golang.org/grpc", Name: "NewClient", Network: noArg, Arg: 0, Kind: ArgTarget},
+}
+
+// AnalyzerDial reports outbound connections to hardcoded IPv4 addresses,
+// which fail once the server moves to [::1] or an IPv6-only host.
+var AnalyzerDial = &analysis.Analyzer{
+	Name:     "ipv4dial",
+	Doc:      "Reports dials (see DialAPIs) to a hardcoded IPv4 address.",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runDial,
+}
+
+func runDial(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	eval := newStrEvaluator(pass, inspect)
+	nodeFilter := []ast.Node{
+		(*ast.CallExpr)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		api := matchAPI(typeutil.StaticCallee(pass.TypesInfo, call), DialAPIs)
+		if api == nil {
+			return
+		}
+		host, ok := apiIPv4Host(eval, api, call)
+		if !ok {
+			return
+		}
+		if host.IsLoopback() {
+			pass.Reportf(call.Pos(), "dial to hardcoded IPv4 loopback address '%s' fails when the server listens on [::1]; dial \"localhost\" instead, which tries ::1 and 127.0.0.1", host)
+			return
+		}
+		pass.Reportf(call.Pos(), "dial to hardcoded IPv4 address '%s' fails on IPv6-only hosts; dial a hostname so both address families are tried", host)
+	})
+	return nil, nil
+}
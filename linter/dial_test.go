@@ -0,0 +1,11 @@
+package linter
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestDial(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData()+"/dial", AnalyzerDial)
+}
@@ -0,0 +1,80 @@
+package linter
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// directive is a //ip6check:NAME reason comment
+type directive struct {
+	pos    token.Pos
+	reason string
+	// alone is set when no code precedes the comment on its line, so it
+	// applies to the line below
+	alone bool
+}
+
+// directiveIndex finds //ip6check:NAME comments by file and line
+type directiveIndex struct {
+	fset  *token.FileSet
+	lines map[string]map[int]directive
+}
+
+// newDirectiveIndex indexes //ip6check:name comments in the files of pass
+func newDirectiveIndex(pass *analysis.Pass, name string) *directiveIndex {
+	prefix := "//ip6check:" + name
+	idx := &directiveIndex{fset: pass.Fset, lines: make(map[string]map[int]directive)}
+	for _, f := range pass.Files {
+		var code map[int]bool
+		for _, group := range f.Comments {
+			for _, c := range group.List {
+				rest, ok := strings.CutPrefix(c.Text, prefix)
+				if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+					continue
+				}
+				// the reason ends at a following comment
+				rest, _, _ = strings.Cut(rest, "//")
+				if code == nil {
+					code = codeLines(pass.Fset, f)
+				}
+				pos := pass.Fset.Position(c.Pos())
+				if idx.lines[pos.Filename] == nil {
+					idx.lines[pos.Filename] = make(map[int]directive)
+				}
+				idx.lines[pos.Filename][pos.Line] = directive{pos: c.Pos(), reason: strings.TrimSpace(rest), alone: !code[pos.Line]}
+			}
+		}
+	}
+	return idx
+}
+
+// find returns the directive on the line of node, or alone on the line
+// above.  A trailing comment on the line above belongs to that line's code
+func (idx *directiveIndex) find(node ast.Node) (directive, bool) {
+	pos := idx.fset.Position(node.Pos())
+	lines := idx.lines[pos.Filename]
+	if d, ok := lines[pos.Line]; ok {
+		return d, true
+	}
+	d, ok := lines[pos.Line-1]
+	return d, ok && d.alone
+}
+
+// codeLines returns the lines of f where a node starts or ends, i.e. the
+// lines holding code rather than only comments
+func codeLines(fset *token.FileSet, f *ast.File) map[int]bool {
+	lines := make(map[int]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.File, *ast.CommentGroup, *ast.Comment:
+			return n != nil
+		}
+		lines[fset.Position(n.Pos()).Line] = true
+		lines[fset.Position(n.End()).Line] = true
+		return true
+	})
+	return lines
+}
@@ -0,0 +1,186 @@
+package linter
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// AnalyzerFirstIP4 reports "pick my IP" loops: ranging over
+// net.InterfaceAddrs() or net.Interface.Addrs() and returning the first
+// address with To4() != nil.  The host's IPv6 addresses are never returned
+var AnalyzerFirstIP4 = &analysis.Analyzer{
+	Name:     "firstipv4",
+	Doc:      "Reports loops over interface addresses returning the first IPv4 address and skipping IPv6.",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runFirstIP4,
+}
+
+// interfaceAddrFuncs return the addresses of the host's interfaces
+var interfaceAddrFuncs = map[string]bool{
+	"net.InterfaceAddrs":  true,
+	"net.Interface.Addrs": true,
+}
+
+func runFirstIP4(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	// isAddrsCall reports whether expr calls net.InterfaceAddrs() or
+	// Addrs()
+	isAddrsCall := func(expr ast.Expr) (string, bool) {
+		call, ok := ast.Unparen(expr).(*ast.CallExpr)
+		if !ok {
+			return "", false
+		}
+		fn, _ := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if fn == nil || !interfaceAddrFuncs[funcName(fn)] {
+			return "", false
+		}
+		return types.ExprString(call), true
+	}
+
+	nodeFilter := []ast.Node{
+		(*ast.RangeStmt)(nil),
+	}
+	inspect.WithStack(nodeFilter, func(node ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		loop := node.(*ast.RangeStmt)
+		src, ok := isAddrsCall(loop.X)
+		if !ok {
+			// addrs, err := net.InterfaceAddrs()
+			def := assignedCall(pass, stack, loop.X)
+			if def == nil {
+				return true
+			}
+			if src, ok = isAddrsCall(def); !ok {
+				return true
+			}
+		}
+		if returnsFirstV4(loop.Body) {
+			pass.Reportf(loop.Pos(), "loop over %s returns the first IPv4 address and skips the host's IPv6 addresses; return the addresses of both families, or choose one by RFC 6724 preference", src)
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// assignedCall returns the call assigned to the variable x by a
+// multi-value assignment in the enclosing function, e.g.
+// addrs, err := net.InterfaceAddrs()
+func assignedCall(pass *analysis.Pass, stack []ast.Node, x ast.Expr) ast.Expr {
+	ident, ok := ast.Unparen(x).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	v, ok := pass.TypesInfo.Uses[ident].(*types.Var)
+	if !ok {
+		return nil
+	}
+	var body *ast.BlockStmt
+	for i := len(stack) - 1; i >= 0 && body == nil; i-- {
+		switch fn := stack[i].(type) {
+		case *ast.FuncDecl:
+			body = fn.Body
+		case *ast.FuncLit:
+			body = fn.Body
+		}
+	}
+	if body == nil {
+		return nil
+	}
+	var call ast.Expr
+	ast.Inspect(body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Rhs) != 1 {
+			return call == nil
+		}
+		for _, lhs := range assign.Lhs {
+			if id, ok := lhs.(*ast.Ident); ok && pass.TypesInfo.ObjectOf(id) == v {
+				call = assign.Rhs[0]
+			}
+		}
+		return call == nil
+	})
+	return call
+}
+
+// returnsFirstV4 reports whether the loop body returns from inside
+// if ip.To4() != nil { ... }, or after if ip.To4() == nil { continue }
+func returnsFirstV4(body *ast.BlockStmt) bool {
+	for i, stmt := range body.List {
+		ifStmt, ok := stmt.(*ast.IfStmt)
+		if !ok {
+			continue
+		}
+		if implies(ifStmt.Cond, true, isV4Check) && returnsValue(ifStmt.Body) {
+			return true
+		}
+		if implies(ifStmt.Cond, false, isV4Check) && continues(ifStmt.Body) {
+			rest := &ast.BlockStmt{List: body.List[i+1:]}
+			if returnsValue(rest) {
+				return true
+			}
+		}
+		if returnsFirstV4(ifStmt.Body) {
+			return true
+		}
+	}
+	return false
+}
+
+// isV4Check reports whether atom evaluating to want proves some address is
+// IPv4: ip.To4() != nil or addr.Is4()
+func isV4Check(atom ast.Expr, want bool) bool {
+	switch atom := atom.(type) {
+	case *ast.BinaryExpr:
+		if other, ok := nilComparison(atom); ok && isMethodCall(other, "To4") {
+			return want == (atom.Op == token.NEQ)
+		}
+	case *ast.CallExpr:
+		return want && isMethodCall(atom, "Is4")
+	}
+	return false
+}
+
+// isMethodCall reports whether expr calls a method named method without
+// arguments
+func isMethodCall(expr ast.Expr, method string) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == method
+}
+
+// returnsValue reports whether block has a return statement with results,
+// outside nested function literals
+func returnsValue(block *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(block, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			found = found || len(n.Results) > 0
+		}
+		return !found
+	})
+	return found
+}
+
+// continues reports whether block ends with continue
+func continues(block *ast.BlockStmt) bool {
+	if len(block.List) == 0 {
+		return false
+	}
+	branch, ok := block.List[len(block.List)-1].(*ast.BranchStmt)
+	return ok && branch.Tok == token.CONTINUE
+}
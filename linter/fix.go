@@ -0,0 +1,81 @@
+package linter
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// render prints expr as Go source for a SuggestedFix
+func render(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, expr); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// fileOf returns the file of pass containing pos
+func fileOf(pass *analysis.Pass, pos token.Pos) *ast.File {
+	for _, f := range pass.Files {
+		if f.FileStart <= pos && pos < f.FileEnd {
+			return f
+		}
+	}
+	return nil
+}
+
+// importNames returns the names file uses for the standard library
+// packages paths, e.g. "net" for "net".  Packages that are not imported
+// yet are added by the returned edit
+func importNames(file *ast.File, paths ...string) (map[string]string, []analysis.TextEdit) {
+	names := make(map[string]string, len(paths))
+	var missing bytes.Buffer
+	for _, path := range paths {
+		if _, ok := names[path]; ok {
+			continue
+		}
+		names[path] = importedName(file, path)
+		if names[path] == "" {
+			names[path] = path[strings.LastIndexByte(path, '/')+1:]
+			missing.WriteString("\n\t" + strconv.Quote(path))
+		}
+	}
+	if missing.Len() == 0 {
+		return names, nil
+	}
+	// add to the first import block, or a new import declaration
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if gen.Lparen.IsValid() {
+			return names, []analysis.TextEdit{{Pos: gen.Lparen + 1, End: gen.Lparen + 1, NewText: missing.Bytes()}}
+		}
+		return names, []analysis.TextEdit{{Pos: gen.Pos(), End: gen.Pos(), NewText: []byte("import (" + missing.String() + "\n)\n")}}
+	}
+	return names, []analysis.TextEdit{{Pos: file.Name.End(), End: file.Name.End(), NewText: []byte("\n\nimport (" + missing.String() + "\n)")}}
+}
+
+// importedName returns the name file uses for path, or "" if it is not
+// imported by name
+func importedName(file *ast.File, path string) string {
+	for _, spec := range file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p != path {
+			continue
+		}
+		if spec.Name == nil {
+			return path[strings.LastIndexByte(path, '/')+1:]
+		}
+		if spec.Name.Name != "_" && spec.Name.Name != "." {
+			return spec.Name.Name
+		}
+	}
+	return ""
+}
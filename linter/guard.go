@@ -0,0 +1,157 @@
+package linter
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// v4Checks are the methods whose result proves an address is IPv4:
+// net.IP.To4() != nil, netip.Addr.Is4() and netip.Addr.Is4In6()
+var v4Checks = map[string]bool{
+	"To4":    true,
+	"Is4":    true,
+	"Is4In6": true,
+}
+
+// sameValue reports whether a and b spell the same value, e.g. both "ip"
+// or both "s.addr"
+func sameValue(a, b ast.Expr) bool {
+	return types.ExprString(ast.Unparen(a)) == types.ExprString(ast.Unparen(b))
+}
+
+// atomFunc reports whether the condition atom evaluating to want proves a
+// fact, e.g. that x is IPv4
+type atomFunc func(atom ast.Expr, want bool) bool
+
+// implies reports whether cond evaluating to want proves the fact checked
+// by atom.  !, && and || are decomposed
+func implies(cond ast.Expr, want bool, atom atomFunc) bool {
+	switch cond := ast.Unparen(cond).(type) {
+	case *ast.UnaryExpr:
+		if cond.Op == token.NOT {
+			return implies(cond.X, !want, atom)
+		}
+	case *ast.BinaryExpr:
+		switch cond.Op {
+		case token.LAND:
+			// a && b true: both are true
+			return want && (implies(cond.X, true, atom) || implies(cond.Y, true, atom))
+		case token.LOR:
+			// a || b false: both are false
+			return !want && (implies(cond.X, false, atom) || implies(cond.Y, false, atom))
+		}
+	}
+	return atom(ast.Unparen(cond), want)
+}
+
+// impliesV4 reports whether cond evaluating to want proves x is IPv4
+func impliesV4(cond ast.Expr, x ast.Expr, want bool) bool {
+	return implies(cond, want, func(atom ast.Expr, want bool) bool {
+		switch atom := atom.(type) {
+		case *ast.BinaryExpr:
+			// x.To4() != nil
+			if other, ok := nilComparison(atom); ok && isV4Call(other, x, "To4") {
+				return want == (atom.Op == token.NEQ)
+			}
+		case *ast.CallExpr:
+			// x.Is4()
+			return want && (isV4Call(atom, x, "Is4") || isV4Call(atom, x, "Is4In6"))
+		}
+		return false
+	})
+}
+
+// nilComparison returns the operand compared to nil by == or !=
+func nilComparison(cond *ast.BinaryExpr) (ast.Expr, bool) {
+	if cond.Op != token.EQL && cond.Op != token.NEQ {
+		return nil, false
+	}
+	switch {
+	case isNil(cond.Y):
+		return cond.X, true
+	case isNil(cond.X):
+		return cond.Y, true
+	}
+	return nil, false
+}
+
+// isV4Call reports whether expr is x.method()
+func isV4Call(expr ast.Expr, x ast.Expr, method string) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == method && sameValue(sel.X, x)
+}
+
+func isNil(expr ast.Expr) bool {
+	ident, ok := ast.Unparen(expr).(*ast.Ident)
+	return ok && ident.Name == "nil"
+}
+
+// guardedV4 reports whether the node on top of stack only runs when x is
+// IPv4: inside if x.To4() != nil { ... }, after an early
+// if x.To4() == nil { return }, or on the right of x.Is4() && ...
+func guardedV4(stack []ast.Node, x ast.Expr) bool {
+	return guarded(stack, func(cond ast.Expr, want bool) bool {
+		return impliesV4(cond, x, want)
+	})
+}
+
+// guarded reports whether the node on top of stack only runs when a
+// condition proves the fact checked by proves
+func guarded(stack []ast.Node, proves func(cond ast.Expr, want bool) bool) bool {
+	for i := len(stack) - 2; i >= 0; i-- {
+		child := stack[i+1]
+		switch parent := stack[i].(type) {
+		case *ast.IfStmt:
+			if child == parent.Body && proves(parent.Cond, true) {
+				return true
+			}
+			if child == parent.Else && proves(parent.Cond, false) {
+				return true
+			}
+		case *ast.BinaryExpr:
+			if child == parent.Y && parent.Op == token.LAND && proves(parent.X, true) {
+				return true
+			}
+			if child == parent.Y && parent.Op == token.LOR && proves(parent.X, false) {
+				return true
+			}
+		case *ast.BlockStmt:
+			for _, stmt := range parent.List {
+				if stmt == child {
+					break
+				}
+				if ifStmt, ok := stmt.(*ast.IfStmt); ok && ifStmt.Else == nil &&
+					terminates(ifStmt.Body) && proves(ifStmt.Cond, false) {
+					return true
+				}
+			}
+		case *ast.FuncDecl, *ast.FuncLit:
+			return false
+		}
+	}
+	return false
+}
+
+// terminates reports whether block ends in return, break, continue, goto
+// or panic
+func terminates(block *ast.BlockStmt) bool {
+	if len(block.List) == 0 {
+		return false
+	}
+	switch last := block.List[len(block.List)-1].(type) {
+	case *ast.ReturnStmt, *ast.BranchStmt:
+		return true
+	case *ast.ExprStmt:
+		if call, ok := last.X.(*ast.CallExpr); ok {
+			if ident, ok := call.Fun.(*ast.Ident); ok && ident.Name == "panic" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
 )
 
 // Analyzer is the entry point for our linter.
@@ -20,20 +21,23 @@ func runIP4Byte(pass *analysis.Pass) (interface{}, error) {
 	// We'll store all variables we've identified as being of type net.IP.
 	// This map lets us quickly check if a variable is an IP address.
 	ipv4AssumedVars := make(map[types.Object]bool)
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
 
 	// --- Pass 1: Identify all variables of type net.IP ---
 	// This pass is essential to correctly type-check all variables
 	// before we analyze how they are used.
-	ast.Inspect(pass.Files[0], func(n ast.Node) bool {
+	declFilter := []ast.Node{
+		(*ast.AssignStmt)(nil),
+		(*ast.FuncDecl)(nil),
+	}
+	inspect.Preorder(declFilter, func(n ast.Node) {
 		if assign, ok := n.(*ast.AssignStmt); ok {
 			for _, lhs := range assign.Lhs {
 				if ident, ok := lhs.(*ast.Ident); ok {
 					// Check if the variable is defined and of type net.IP.
 					if obj := pass.TypesInfo.ObjectOf(ident); obj != nil {
-						if t, ok := obj.Type().(*types.Named); ok {
-							if t.Obj().Pkg().Path() == "net" && t.Obj().Name() == "IP" {
-								ipv4AssumedVars[obj] = true
-							}
+						if isNamedType(obj.Type(), "net", "IP") {
+							ipv4AssumedVars[obj] = true
 						}
 					}
 				}
@@ -45,23 +49,23 @@ func runIP4Byte(pass *analysis.Pass) (interface{}, error) {
 				for _, param := range fn.Type.Params.List {
 					for _, name := range param.Names {
 						if obj := pass.TypesInfo.ObjectOf(name); obj != nil {
-							if t, ok := obj.Type().(*types.Named); ok {
-								if t.Obj().Pkg().Path() == "net" && t.Obj().Name() == "IP" {
-									ipv4AssumedVars[obj] = true
-								}
+							if isNamedType(obj.Type(), "net", "IP") {
+								ipv4AssumedVars[obj] = true
 							}
 						}
 					}
 				}
 			}
 		}
-
-		return true
 	})
 
 	// --- Pass 2: Check how these net.IP variables are used ---
 	// This is the core logic that checks for bad patterns.
-	ast.Inspect(pass.Files[0], func(n ast.Node) bool {
+	useFilter := []ast.Node{
+		(*ast.SliceExpr)(nil),
+		(*ast.IndexExpr)(nil),
+	}
+	inspect.Preorder(useFilter, func(n ast.Node) {
 		// Detect fixed-length slicing (e.g., ip[0:4]).
 		if slice, ok := n.(*ast.SliceExpr); ok {
 			if ident, ok := slice.X.(*ast.Ident); ok {
@@ -94,8 +98,6 @@ func runIP4Byte(pass *analysis.Pass) (interface{}, error) {
 				}
 			}
 		}
-
-		return true
 	})
 
 	return nil, nil
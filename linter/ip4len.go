@@ -0,0 +1,245 @@
+package linter
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// AnalyzerIP4Len reports net.IP length checks and integer conversions that
+// assume 4 bytes.  net.ParseIP returns 16 bytes for IPv4 addresses too, so
+// these are only correct after To4() or Is4()
+var AnalyzerIP4Len = &analysis.Analyzer{
+	Name:     "ipv4length",
+	Doc:      "Reports net.IP length checks, 4-byte allocations and uint32 conversions without a To4() or Is4() guard.",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runIP4Len,
+}
+
+func runIP4Len(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	eval := newStrEvaluator(pass, inspect)
+
+	isIP := func(expr ast.Expr) bool {
+		return isNamedType(pass.TypesInfo.TypeOf(expr), "net", "IP")
+	}
+	isFour := func(expr ast.Expr) bool {
+		v, ok := eval.constInt(expr)
+		return ok && v == 4
+	}
+	// isFourBytes reports whether x is known to be 4 bytes: a To4() result,
+	// directly or reassigned with ip = ip.To4() and checked against nil, or
+	// make(net.IP, 4), which is reported on its own
+	isFourBytes := func(x ast.Expr, stack []ast.Node) bool {
+		if isTo4Result(eval, x) || reassignedTo4(stack, x, eval) {
+			return true
+		}
+		alloc, ok := eval.resolve(x).(*ast.CallExpr)
+		return ok && isBuiltinCall(pass, alloc, "make") && len(alloc.Args) >= 2 && isIP(alloc.Args[0]) && isFour(alloc.Args[1])
+	}
+	// unguarded reports whether x is a net.IP not known to be 4 bytes.
+	// ip.To4() != nil does not shorten ip: only the To4() result, or a
+	// value whose length was checked, is 4 bytes
+	unguarded := func(x ast.Expr, stack []ast.Node) bool {
+		if !isIP(x) || isFourBytes(x, stack) {
+			return false
+		}
+		return !guardedLen4(stack, x, eval)
+	}
+
+	nodeFilter := []ast.Node{
+		(*ast.BinaryExpr)(nil),
+		(*ast.CallExpr)(nil),
+	}
+	inspect.WithStack(nodeFilter, func(node ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		switch node := node.(type) {
+		case *ast.BinaryExpr:
+			// len(ip) == 4, len(ip) != net.IPv4len
+			if node.Op != token.EQL && node.Op != token.NEQ {
+				return true
+			}
+			lenCall, size := node.X, node.Y
+			if !isBuiltinCall(pass, lenCall, "len") {
+				lenCall, size = size, lenCall
+			}
+			if !isBuiltinCall(pass, lenCall, "len") || !isFour(size) {
+				return true
+			}
+			if x := lenCall.(*ast.CallExpr).Args[0]; isIP(x) && !isFourBytes(x, stack) {
+				pass.Reportf(node.Pos(), "len(%s) compared to 4: net.IP holds IPv4 addresses in 16 bytes too; use %s.To4() != nil", types.ExprString(x), types.ExprString(x))
+			}
+		case *ast.CallExpr:
+			switch {
+			case isBuiltinCall(pass, node, "make"):
+				// make(net.IP, 4)
+				if len(node.Args) >= 2 && isIP(node.Args[0]) && isFour(node.Args[1]) {
+					pass.Reportf(node.Pos(), "4-byte net.IP cannot hold an IPv6 address; allocate net.IPv6len or use netip.Addr")
+				}
+			case isBuiltinCall(pass, node, "copy"):
+				// copy(ip[12:], v4)
+				slice, ok := ast.Unparen(node.Args[0]).(*ast.SliceExpr)
+				if !ok || slice.Low == nil {
+					return true
+				}
+				if v, ok := eval.constInt(slice.Low); ok && v == 12 && unguarded(slice.X, stack) {
+					pass.Reportf(node.Pos(), "copy into %s[12:] assumes the IPv4-in-IPv6 layout; use net.IPv4() or netip.AddrFrom4", types.ExprString(slice.X))
+				}
+			default:
+				// binary.BigEndian.Uint32(ip)
+				fn := typeutil.Callee(pass.TypesInfo, node)
+				if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != "encoding/binary" ||
+					(fn.Name() != "Uint32" && fn.Name() != "PutUint32") || len(node.Args) == 0 {
+					return true
+				}
+				x := node.Args[0]
+				if !unguarded(x, stack) {
+					return true
+				}
+				if fn.Name() == "PutUint32" {
+					pass.Reportf(node.Pos(), "PutUint32 into net.IP %s writes the first 4 of 16 bytes; write into ip4 := %s.To4() after checking ip4 != nil, or use netip.AddrFrom4", types.ExprString(x), types.ExprString(x))
+					return true
+				}
+				pass.Reportf(node.Pos(), "Uint32 of net.IP %s reads the first 4 of 16 bytes; use ip4 := %s.To4() and check ip4 != nil, or use netip.Addr.As4()", types.ExprString(x), types.ExprString(x))
+			}
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// guardedLen4 reports whether the node on top of stack only runs when
+// len(x) == 4
+func guardedLen4(stack []ast.Node, x ast.Expr, eval *strEvaluator) bool {
+	return guarded(stack, func(cond ast.Expr, want bool) bool {
+		return implies(cond, want, func(atom ast.Expr, want bool) bool {
+			return isLen4Check(eval, atom, x, want)
+		})
+	})
+}
+
+// isLen4Check reports whether atom evaluating to want proves len(x) == 4:
+// len(x) == 4 is true, or len(x) != net.IPv4len is false
+func isLen4Check(eval *strEvaluator, atom ast.Expr, x ast.Expr, want bool) bool {
+	bin, ok := atom.(*ast.BinaryExpr)
+	if !ok || (bin.Op != token.EQL && bin.Op != token.NEQ) {
+		return false
+	}
+	lenCall, size := bin.X, bin.Y
+	if !isBuiltinCall(eval.pass, lenCall, "len") {
+		lenCall, size = size, lenCall
+	}
+	if !isBuiltinCall(eval.pass, lenCall, "len") || !sameValue(lenCall.(*ast.CallExpr).Args[0], x) {
+		return false
+	}
+	v, ok := eval.constInt(size)
+	return ok && v == 4 && want == (bin.Op == token.EQL)
+}
+
+// reassignedTo4 reports whether the variable x was last assigned
+// x = x.To4() and is checked against nil, e.g.
+// ip = ip.To4(); if ip == nil { return }
+func reassignedTo4(stack []ast.Node, x ast.Expr, eval *strEvaluator) bool {
+	ident, ok := ast.Unparen(x).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	v, ok := eval.pass.TypesInfo.Uses[ident].(*types.Var)
+	if !ok || !guardedNonNil(stack, x, eval) {
+		return false
+	}
+	for i := len(stack) - 2; i >= 0; i-- {
+		child := stack[i+1]
+		// the statements run before child, innermost last
+		var before []ast.Stmt
+		switch parent := stack[i].(type) {
+		case *ast.BlockStmt:
+			for _, stmt := range parent.List {
+				if stmt == child {
+					break
+				}
+				before = append(before, stmt)
+			}
+		case *ast.IfStmt:
+			if parent.Init != nil && child != parent.Init {
+				before = append(before, parent.Init)
+			}
+		case *ast.FuncDecl, *ast.FuncLit:
+			return false
+		}
+		for j := len(before) - 1; j >= 0; j-- {
+			if to4, assigns := assignsTo4(eval, before[j], v); assigns {
+				return to4
+			}
+		}
+	}
+	return false
+}
+
+// assignsTo4 reports whether stmt assigns the variable v, and whether that
+// assignment is v = v.To4().  Assignments nested in stmt make v unknown
+func assignsTo4(eval *strEvaluator, stmt ast.Stmt, v *types.Var) (to4, assigns bool) {
+	isV := func(expr ast.Expr) bool {
+		ident, ok := ast.Unparen(expr).(*ast.Ident)
+		return ok && eval.pass.TypesInfo.ObjectOf(ident) == v
+	}
+	if assign, ok := stmt.(*ast.AssignStmt); ok && len(assign.Lhs) == 1 && len(assign.Rhs) == 1 && isV(assign.Lhs[0]) {
+		call, ok := ast.Unparen(assign.Rhs[0]).(*ast.CallExpr)
+		if !ok || len(call.Args) != 0 {
+			return false, true
+		}
+		sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+		return ok && sel.Sel.Name == "To4" && isV(sel.X), true
+	}
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if assign, ok := n.(*ast.AssignStmt); ok {
+			for _, lhs := range assign.Lhs {
+				assigns = assigns || isV(lhs)
+			}
+		}
+		return !assigns
+	})
+	return false, assigns
+}
+
+// isBuiltinCall reports whether expr calls the builtin name, e.g. len
+func isBuiltinCall(pass *analysis.Pass, expr ast.Expr, name string) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return false
+	}
+	ident, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	b, ok := pass.TypesInfo.Uses[ident].(*types.Builtin)
+	return ok && b.Name() == name
+}
+
+// isTo4Result reports whether x is the result of To4(), directly or through
+// a variable assigned once
+func isTo4Result(eval *strEvaluator, x ast.Expr) bool {
+	_, ok := to4Receiver(eval, x)
+	return ok
+}
+
+// to4Receiver returns ip when x is the result of ip.To4(), directly or
+// through a variable assigned once
+func to4Receiver(eval *strEvaluator, x ast.Expr) (ast.Expr, bool) {
+	call, ok := eval.resolve(x).(*ast.CallExpr)
+	if !ok {
+		return nil, false
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "To4" || !isNamedType(eval.pass.TypesInfo.TypeOf(sel.X), "net", "IP") {
+		return nil, false
+	}
+	return sel.X, true
+}
@@ -0,0 +1,168 @@
+package linter
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// AnalyzerIP4Wildcard reports listening on the IPv4 wildcard 0.0.0.0, e.g.
+// net.Listen("tcp", "0.0.0.0:8080") or &net.TCPAddr{IP: net.IPv4zero}.
+// ":8080" listens on all interfaces of both families
+var AnalyzerIP4Wildcard = &analysis.Analyzer{
+	Name:     "ipv4wildcard",
+	Doc:      "Reports listen calls (see ListenAPIs and ListenFields) on the IPv4 wildcard 0.0.0.0 instead of \":PORT\".",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runIP4Wildcard,
+}
+
+const ipv4Wildcard = "0.0.0.0"
+
+func runIP4Wildcard(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	eval := newStrEvaluator(pass, inspect)
+	// fixes edit the definition of a variable only when the listen call is
+	// its sole use
+	uses := make(map[types.Object]int)
+	for _, obj := range pass.TypesInfo.Uses {
+		uses[obj]++
+	}
+	report := func(node ast.Node, fix *analysis.SuggestedFix) {
+		diag := analysis.Diagnostic{
+			Pos:     node.Pos(),
+			End:     node.End(),
+			Message: "listening on " + ipv4Wildcard + " exposes the service on all IPv4 interfaces but none of the IPv6 ones; use \":PORT\" to listen on both",
+		}
+		if fix != nil {
+			diag.SuggestedFixes = []analysis.SuggestedFix{*fix}
+		}
+		pass.Report(diag)
+	}
+	// checkHostPort reports a "0.0.0.0:port" string
+	checkHostPort := func(addr ast.Expr) {
+		host, _, ok := eval.eval(addr).hostPort()
+		if !ok || !isIPv4Wildcard(host) {
+			return
+		}
+		report(addr, wildcardStringFix(eval, uses, addr))
+	}
+
+	forEachListenAddr(pass, inspect, func(api *AddrAPI, call *ast.CallExpr) {
+		host, addr, ok := apiHost(eval, api, call)
+		if !ok || !isIPv4Wildcard(host) {
+			return
+		}
+		switch api.Kind {
+		case ArgHostPort:
+			report(addr, wildcardStringFix(eval, uses, addr))
+		case ArgIPAddr:
+			report(addr, wildcardIPFix(eval, uses, addr))
+		}
+	}, checkHostPort)
+	return nil, nil
+}
+
+// isIPv4Wildcard reports whether host is 0.0.0.0, also spelled
+// ::ffff:0.0.0.0
+func isIPv4Wildcard(host string) bool {
+	addr, ok := parseIPv4(host)
+	return ok && addr.IsUnspecified()
+}
+
+// wildcardStringFix drops "0.0.0.0" from the string literal starting the
+// address: "0.0.0.0:8080", "0.0.0.0:" + port or
+// fmt.Sprintf("0.0.0.0:%d", port)
+func wildcardStringFix(eval *strEvaluator, uses map[types.Object]int, addr ast.Expr) *analysis.SuggestedFix {
+	var first ast.Expr
+	switch expr := resolveSole(eval, uses, addr).(type) {
+	case *ast.BasicLit:
+		first = expr
+	case *ast.BinaryExpr:
+		first = concatOperands(expr)[0]
+	case *ast.CallExpr:
+		if isPkgFunc(typeutil.StaticCallee(eval.pass.TypesInfo, expr), "fmt", "Sprintf") && len(expr.Args) > 0 {
+			first = expr.Args[0]
+		}
+	}
+	lit, ok := first.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil || !strings.HasPrefix(s, ipv4Wildcard+":") {
+		return nil
+	}
+	return &analysis.SuggestedFix{
+		Message: "Listen on \":PORT\"",
+		TextEdits: []analysis.TextEdit{{
+			Pos:     lit.Pos(),
+			End:     lit.End(),
+			NewText: []byte(strconv.Quote(strings.TrimPrefix(s, ipv4Wildcard))),
+		}},
+	}
+}
+
+// wildcardIPFix removes the IP field of a &net.TCPAddr{IP: net.IPv4zero}
+// literal, a nil IP listens on both families
+func wildcardIPFix(eval *strEvaluator, uses map[types.Object]int, addr ast.Expr) *analysis.SuggestedFix {
+	expr := resolveSole(eval, uses, addr)
+	if u, ok := expr.(*ast.UnaryExpr); ok {
+		expr = resolveSole(eval, uses, u.X)
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	for i, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if key, ok := kv.Key.(*ast.Ident); !ok || key.Name != "IP" {
+			continue
+		}
+		// take the comma along: up to the next field, or from the
+		// previous one
+		edit := analysis.TextEdit{Pos: kv.Pos(), End: kv.End()}
+		switch {
+		case i+1 < len(lit.Elts):
+			edit.End = lit.Elts[i+1].Pos()
+		case i > 0:
+			edit.Pos = lit.Elts[i-1].End()
+		}
+		return &analysis.SuggestedFix{
+			Message:   "Remove the IPv4 wildcard IP",
+			TextEdits: []analysis.TextEdit{edit},
+		}
+	}
+	return nil
+}
+
+// resolveSole is strEvaluator.resolve through variables used only once, so
+// editing their definition changes no other use.  It returns nil when a
+// variable on the way has other uses
+func resolveSole(eval *strEvaluator, uses map[types.Object]int, expr ast.Expr) ast.Expr {
+	for depth := 0; depth < maxEvalDepth && expr != nil; depth++ {
+		expr = ast.Unparen(expr)
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			return expr
+		}
+		if eval.resolve(ident) == ast.Expr(ident) {
+			return expr
+		}
+		if uses[eval.pass.TypesInfo.ObjectOf(ident)] != 1 {
+			return nil
+		}
+		v := eval.pass.TypesInfo.ObjectOf(ident).(*types.Var)
+		expr = eval.defs[v]
+	}
+	return expr
+}
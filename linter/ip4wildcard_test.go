@@ -0,0 +1,11 @@
+package linter
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestIP4Wildcard(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData()+"/ip4wildcard", AnalyzerIP4Wildcard)
+}
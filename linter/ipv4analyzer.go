@@ -1,12 +1,12 @@
 package linter
 
 import (
-	//"go/analysis"
 	"go/ast"
-	"go/token"
-	"strings"
+	"net/netip"
 
 	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
 )
 
 var Analyzers []*analysis.Analyzer = make([]*analysis.Analyzer, 0)
@@ -15,58 +15,81 @@ func init() {
 	Analyzers = append(Analyzers, AnalyzerIP4)
 	Analyzers = append(Analyzers, AnalyzerParseIP)
 	Analyzers = append(Analyzers, AnalyzerIP4Byte)
+	Analyzers = append(Analyzers, AnalyzerDial)
+	Analyzers = append(Analyzers, AnalyzerJoinHostPort)
+	Analyzers = append(Analyzers, AnalyzerSplitHostPort)
+	Analyzers = append(Analyzers, AnalyzerV4Network)
+	Analyzers = append(Analyzers, AnalyzerIP4Len)
+	Analyzers = append(Analyzers, AnalyzerTo4Nil)
+	Analyzers = append(Analyzers, AnalyzerIP4Wildcard)
+	Analyzers = append(Analyzers, AnalyzerLocalhost)
+	Analyzers = append(Analyzers, AnalyzerLoopbackString)
+	Analyzers = append(Analyzers, AnalyzerFirstIP4)
+	Analyzers = append(Analyzers, AnalyzerURLHost)
 }
 
 // Analyzer is the core component of our static analysis checker.
 // It defines the name, documentation, and the function that performs the analysis.
 var AnalyzerIP4 = &analysis.Analyzer{
-	Name: "ipv4checker",
-	Doc:  "Reports calls to net.Listen using a hardcoded IPv4 loopback address.",
-	Run:  runIP4,
+	Name:     "ipv4checker",
+	Doc:      "Reports listen calls (see ListenAPIs and ListenFields) using a hardcoded IPv4 address.",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runIP4,
 }
 
 // run is the main function that inspects the AST of the Go source files.
 func runIP4(pass *analysis.Pass) (interface{}, error) {
-	// Traverse the Abstract Syntax Tree (AST) of each file in the pass.
-	for _, file := range pass.Files {
-		// ast.Inspect traverses the nodes of the AST.
-		ast.Inspect(file, func(node ast.Node) bool {
-			// Check if the current node is a function call expression.
-			callExpr, ok := node.(*ast.CallExpr)
-			if !ok {
-				return true // Not a function call, continue to the next node.
-			}
-
-			// Check if the function being called is 'net.Listen'.
-			fun, ok := callExpr.Fun.(*ast.SelectorExpr)
-			if !ok {
-				return true // Not a selector expression (e.g., package.Function), continue.
-			}
-
-			// Ensure the selector is 'net.Listen'.
-			if pkg, ok := fun.X.(*ast.Ident); ok && pkg.Name == "net" && fun.Sel.Name == "Listen" {
-				// We've found a call to net.Listen. Now check its arguments.
-				if len(callExpr.Args) != 2 {
-					return true // The function call doesn't have the expected two arguments.
-				}
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	eval := newStrEvaluator(pass, inspect)
+	report := func(node ast.Node, host netip.Addr) {
+		// 0.0.0.0 is reported by AnalyzerIP4Wildcard
+		if host.IsUnspecified() {
+			return
+		}
+		kind := "IPv4"
+		if host.IsLoopback() {
+			kind = "IPv4 loopback"
+		}
+		pass.Reportf(node.Pos(), "found hardcoded %s address '%s'; consider using a dual-stack address like \":PORT\" for better compatibility.", kind, host)
+	}
+	forEachListenAddr(pass, inspect, func(api *AddrAPI, call *ast.CallExpr) {
+		if host, ok := apiIPv4Host(eval, api, call); ok {
+			report(call, host)
+		}
+	}, func(addr ast.Expr) {
+		// http.Server{Addr: "127.0.0.1:8080"}, srv.Addr = "127.0.0.1:8080"
+		if host, ok := ipv4Host(eval.eval(addr)); ok {
+			report(addr, host)
+		}
+	})
+	return nil, nil
+}
 
-				// Check if the first argument is the string literal "tcp".
-				networkArg, ok := callExpr.Args[0].(*ast.BasicLit)
-				if !ok || networkArg.Kind != token.STRING || strings.Trim(networkArg.Value, `"`) != "tcp" {
-					return true // Not a call to net.Listen("tcp", ...), continue.
-				}
+// apiIPv4Host returns the IPv4 host passed to a call of api
+func apiIPv4Host(eval *strEvaluator, api *AddrAPI, call *ast.CallExpr) (netip.Addr, bool) {
+	host, _, ok := apiHost(eval, api, call)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	return parseIPv4(host)
+}
 
-				// Check if the second argument is the string literal "127.0.0.1".
-				addressArg, ok := callExpr.Args[1].(*ast.BasicLit)
-				if !ok || addressArg.Kind != token.STRING || !strings.Contains(addressArg.Value, ".") {
-					return true // Not a call to net.Listen("...", "127.0.0.1"), continue.
-				}
+// ipv4Host returns the host of a "host:port" address when it is an IPv4 (or
+// IPv4-mapped IPv6) literal.  Hostnames like "localhost.example" are not IPv4.
+func ipv4Host(address strValue) (netip.Addr, bool) {
+	host, _, ok := address.hostPort()
+	if !ok {
+		return netip.Addr{}, false
+	}
+	return parseIPv4(host)
+}
 
-				// All conditions are met. Report the issue.
-				pass.Reportf(callExpr.Pos(), "found hardcoded IPv4 loopback address '127.0.0.1'; consider using a dual-stack address like \":PORT\" for better compatibility.")
-			}
-			return true // Continue inspecting the next node.
-		})
+// parseIPv4 parses host as an IPv4 (or IPv4-mapped IPv6) literal
+func parseIPv4(host string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
 	}
-	return nil, nil
+	addr = addr.Unmap()
+	return addr, addr.Is4()
 }
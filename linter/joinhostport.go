@@ -0,0 +1,398 @@
+package linter
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// HostPortFields are struct fields holding "host:port", checked by
+// AnalyzerJoinHostPort in addition to ListenFields
+var HostPortFields = []AddrField{
+	{Pkg: "net/url", Type: "URL", Field: "Host"},
+}
+
+// AnalyzerJoinHostPort reports "host:port" strings built with fmt.Sprintf or
+// concatenation.  With an IPv6 host they produce "::1:8080" instead of
+// "[::1]:8080"
+var AnalyzerJoinHostPort = &analysis.Analyzer{
+	Name:     "joinhostport",
+	Doc:      "Reports host:port addresses built with fmt.Sprintf or + instead of net.JoinHostPort.",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runJoinHostPort,
+}
+
+func runJoinHostPort(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	eval := newStrEvaluator(pass, inspect)
+	hosts := newIPHosts(pass, inspect)
+	fields := append(append([]AddrField(nil), ListenFields...), HostPortFields...)
+	reported := make(map[ast.Expr]bool)
+	check := func(addr ast.Expr, isURL bool) {
+		expr := eval.resolve(addr)
+		if reported[expr] {
+			return
+		}
+		if checkHostPort(pass, eval, hosts, expr, isURL) {
+			reported[expr] = true
+		}
+	}
+
+	nodeFilter := []ast.Node{
+		(*ast.CallExpr)(nil),
+		(*ast.CompositeLit)(nil),
+		(*ast.AssignStmt)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(node ast.Node) {
+		switch node := node.(type) {
+		case *ast.CallExpr:
+			fn := typeutil.StaticCallee(pass.TypesInfo, node)
+			api := matchAPI(fn, ListenAPIs)
+			if api == nil {
+				api = matchAPI(fn, DialAPIs)
+			}
+			if api == nil || api.Arg >= len(node.Args) {
+				return
+			}
+			if api.Kind == ArgHostPort || api.Kind == ArgURL || api.Kind == ArgTarget {
+				check(node.Args[api.Arg], api.Kind == ArgURL)
+			}
+		case *ast.CompositeLit:
+			t := pass.TypesInfo.TypeOf(node)
+			for _, elt := range node.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					if key, ok := kv.Key.(*ast.Ident); ok && matchField(t, key.Name, fields) != nil {
+						check(kv.Value, false)
+					}
+				}
+			}
+		case *ast.AssignStmt:
+			if len(node.Lhs) != len(node.Rhs) {
+				return
+			}
+			for i, lhs := range node.Lhs {
+				sel, ok := lhs.(*ast.SelectorExpr)
+				if !ok {
+					continue
+				}
+				selection := pass.TypesInfo.Selections[sel]
+				if selection != nil && selection.Kind() == types.FieldVal &&
+					matchField(selection.Recv(), sel.Sel.Name, fields) != nil {
+					check(node.Rhs[i], false)
+				}
+			}
+		}
+	})
+	return nil, nil
+}
+
+// hostPortPair is a host and port joined by ":" within a format string or
+// a concatenation
+type hostPortPair struct {
+	host ast.Expr
+	// port is an expression, or a literal port when portLit is set
+	port    ast.Expr
+	portLit string
+	// portVerb is the fmt verb of port, 0 for concatenation
+	portVerb byte
+}
+
+// checkHostPort reports expr if it is a fmt.Sprintf call or concatenation
+// joining a host and port with ":".  In a URL (isURL) the host must follow
+// a constant "scheme://", otherwise it may be a base URL like
+// "http://localhost" and the ":" starts its port
+func checkHostPort(pass *analysis.Pass, eval *strEvaluator, hosts *ipHosts, expr ast.Expr, isURL bool) bool {
+	switch expr := expr.(type) {
+	case *ast.CallExpr:
+		if !isPkgFunc(typeutil.StaticCallee(pass.TypesInfo, expr), "fmt", "Sprintf") || len(expr.Args) < 1 {
+			return false
+		}
+		return checkSprintf(pass, eval, hosts, expr, isURL)
+	case *ast.BinaryExpr:
+		if expr.Op != token.ADD {
+			return false
+		}
+		return checkConcat(pass, eval, hosts, expr, isURL)
+	}
+	return false
+}
+
+// safeHost reports whether host is statically known to need no brackets,
+// e.g. "localhost" or "127.0.0.1"
+func safeHost(eval *strEvaluator, host ast.Expr) bool {
+	v := eval.eval(host)
+	return v.complete && !strings.Contains(v.prefix, ":")
+}
+
+func checkSprintf(pass *analysis.Pass, eval *strEvaluator, hosts *ipHosts, call *ast.CallExpr, isURL bool) bool {
+	format := eval.eval(call.Args[0])
+	if !format.complete {
+		return false
+	}
+	f := format.prefix
+	// args are counted one per verb
+	if movesArgs(f) {
+		return false
+	}
+	args := call.Args[1:]
+	argIndex := 0
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			continue
+		}
+		if i+1 >= len(f) {
+			return false
+		}
+		verb := f[i+1]
+		if verb == '%' {
+			i++
+			continue
+		}
+		if verb != 's' && verb != 'v' {
+			argIndex++
+			i++
+			continue
+		}
+		// %s followed by ":" and a port verb or literal port
+		rest := f[i+2:]
+		if !strings.HasPrefix(rest, ":") || argIndex >= len(args) {
+			argIndex++
+			i++
+			continue
+		}
+		pair := hostPortPair{host: args[argIndex]}
+		end := i + 3 // after "%s:"
+		if len(rest) >= 3 && rest[1] == '%' && strings.IndexByte("sdv", rest[2]) >= 0 && argIndex+1 < len(args) {
+			pair.port = args[argIndex+1]
+			pair.portVerb = rest[2]
+			end += 2
+		} else if digits := leadingDigits(rest[1:]); digits != "" {
+			pair.portLit = digits
+			end += len(digits)
+		} else {
+			argIndex++
+			i++
+			continue
+		}
+		if safeHost(eval, pair.host) || isURL && !isURLPrefix(f[:i]) {
+			return false
+		}
+		// "http://%s:%d" with an IP host is reported by AnalyzerURLHost
+		if isURLPrefix(f[:i]) && hosts.isIPHost(pair.host) {
+			return false
+		}
+		diag := analysis.Diagnostic{
+			Pos:     call.Pos(),
+			End:     call.End(),
+			Message: "host:port built with fmt.Sprintf is invalid for IPv6 hosts (\"::1:80\"); use net.JoinHostPort",
+		}
+		// only a literal format can be rewritten
+		if _, ok := call.Args[0].(*ast.BasicLit); ok {
+			diag.SuggestedFixes = sprintfFix(pass, call, f[:i]+"%s"+f[end:], argIndex, pair)
+		}
+		pass.Report(diag)
+		return true
+	}
+	return false
+}
+
+// movesArgs reports whether format has a * width or precision, or an
+// explicit argument index like %[2]s, so verbs and arguments do not pair up
+// one to one
+func movesArgs(format string) bool {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		for i++; i < len(format) && strings.IndexByte("+-# 0123456789.*[]", format[i]) >= 0; i++ {
+			if format[i] == '*' || format[i] == '[' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
+
+// sprintfFix replaces the host and port arguments with net.JoinHostPort
+func sprintfFix(pass *analysis.Pass, call *ast.CallExpr, format string, argIndex int, pair hostPortPair) []analysis.SuggestedFix {
+	file := fileOf(pass, call.Pos())
+	if file == nil {
+		return nil
+	}
+	join, edits, ok := joinHostPortText(pass, file, pair)
+	if !ok {
+		return nil
+	}
+	args := call.Args[1:]
+	var newArgs []string
+	for i, arg := range args {
+		switch {
+		case i == argIndex:
+			newArgs = append(newArgs, join)
+		case i == argIndex+1 && pair.port != nil:
+		default:
+			newArgs = append(newArgs, render(pass.Fset, arg))
+		}
+	}
+	var text string
+	if format == "%s" && len(newArgs) == 1 {
+		text = join
+	} else {
+		text = render(pass.Fset, call.Fun) + "(" + strings.Join(append([]string{strconv.Quote(format)}, newArgs...), ", ") + ")"
+	}
+	edits = append(edits, analysis.TextEdit{Pos: call.Pos(), End: call.End(), NewText: []byte(text)})
+	return []analysis.SuggestedFix{{Message: "Use net.JoinHostPort", TextEdits: edits}}
+}
+
+// joinHostPortText renders net.JoinHostPort(host, port).  An integer port
+// is converted with strconv.Itoa, a fmt.Stringer host (net.IP) with String()
+func joinHostPortText(pass *analysis.Pass, file *ast.File, pair hostPortPair) (string, []analysis.TextEdit, bool) {
+	host := render(pass.Fset, pair.host)
+	if !isStringType(pass.TypesInfo.TypeOf(pair.host)) {
+		if !hasStringMethod(pass.TypesInfo.TypeOf(pair.host)) {
+			return "", nil, false
+		}
+		host += ".String()"
+	}
+	paths := []string{"net"}
+	port := strconv.Quote(pair.portLit)
+	var portType *types.Basic
+	if pair.port != nil {
+		port = render(pass.Fset, pair.port)
+		portType, _ = pass.TypesInfo.TypeOf(pair.port).Underlying().(*types.Basic)
+		if portType == nil {
+			return "", nil, false
+		}
+		switch {
+		case portType.Info()&types.IsString != 0:
+			portType = nil
+		case portType.Info()&types.IsInteger != 0:
+			paths = append(paths, "strconv")
+		default:
+			return "", nil, false
+		}
+	}
+	names, edits := importNames(file, paths...)
+	if portType != nil {
+		if portType.Kind() == types.Int || portType.Kind() == types.UntypedInt {
+			port = names["strconv"] + ".Itoa(" + port + ")"
+		} else {
+			port = names["strconv"] + ".Itoa(int(" + port + "))"
+		}
+	}
+	return fmt.Sprintf("%s.JoinHostPort(%s, %s)", names["net"], host, port), edits, true
+}
+
+// isStringType reports whether t is a string type
+func isStringType(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+// hasStringMethod reports whether t has a String() string method
+func hasStringMethod(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, nil, "String")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && isStringType(sig.Results().At(0).Type())
+}
+
+// concatOperands flattens a + b + c into [a b c]
+func concatOperands(expr ast.Expr) []ast.Expr {
+	if b, ok := ast.Unparen(expr).(*ast.BinaryExpr); ok && b.Op == token.ADD {
+		return append(concatOperands(b.X), concatOperands(b.Y)...)
+	}
+	return []ast.Expr{expr}
+}
+
+func checkConcat(pass *analysis.Pass, eval *strEvaluator, hosts *ipHosts, expr *ast.BinaryExpr, isURL bool) bool {
+	ops := concatOperands(expr)
+	for i := 0; i+1 < len(ops); i++ {
+		host := ops[i]
+		sep, ok := eval.constString(ops[i+1])
+		if !ok || !strings.HasPrefix(sep, ":") {
+			continue
+		}
+		// a literal host is part of the text, e.g. "http:" + "//"
+		if _, ok := eval.constString(host); ok {
+			continue
+		}
+		if safeHost(eval, host) {
+			return false
+		}
+		var prefix string
+		if i > 0 {
+			prefix, _ = eval.constString(ops[i-1])
+		}
+		if isURL && !isURLPrefix(prefix) {
+			return false
+		}
+		// "http://" + ip.String() + ":" + port is reported by AnalyzerURLHost
+		if isURLPrefix(prefix) && hosts.isIPHost(host) {
+			return false
+		}
+		pair := hostPortPair{host: host}
+		var rest string
+		end := i + 2
+		if sep == ":" && i+2 < len(ops) {
+			pair.port = ops[i+2]
+			end = i + 3
+		} else if digits := leadingDigits(sep[1:]); digits != "" {
+			pair.portLit = digits
+			rest = sep[1+len(digits):]
+		} else {
+			continue
+		}
+		diag := analysis.Diagnostic{
+			Pos:     expr.Pos(),
+			End:     expr.End(),
+			Message: "host:port built with string concatenation is invalid for IPv6 hosts (\"::1:80\"); use net.JoinHostPort",
+		}
+		if file := fileOf(pass, expr.Pos()); file != nil {
+			if join, edits, ok := joinHostPortText(pass, file, pair); ok {
+				if rest != "" {
+					join += " + " + strconv.Quote(rest)
+				}
+				edits = append(edits, analysis.TextEdit{Pos: host.Pos(), End: ops[end-1].End(), NewText: []byte(join)})
+				diag.SuggestedFixes = []analysis.SuggestedFix{{Message: "Use net.JoinHostPort", TextEdits: edits}}
+			}
+		}
+		pass.Report(diag)
+		return true
+	}
+	return false
+}
+
+// isConstExpr reports whether expr is a constant
+func isConstExpr(pass *analysis.Pass, expr ast.Expr) bool {
+	tv, ok := pass.TypesInfo.Types[expr]
+	return ok && tv.Value != nil
+}
+
+// constString returns the value of a constant string expression
+func (e *strEvaluator) constString(expr ast.Expr) (string, bool) {
+	if !isConstExpr(e.pass, expr) {
+		return "", false
+	}
+	v := e.eval(expr)
+	return v.prefix, v.complete
+}
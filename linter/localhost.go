@@ -0,0 +1,53 @@
+package linter
+
+import (
+	"go/ast"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// AnalyzerLocalhost reports listening on "localhost:PORT".  Listen binds
+// the first address the name resolves to, ::1 or 127.0.0.1 depending on
+// /etc/hosts, and clients dialing the other family are refused
+var AnalyzerLocalhost = &analysis.Analyzer{
+	Name:     "localhostlisten",
+	Doc:      "Reports listen calls (see ListenAPIs and ListenFields) on \"localhost\", which binds only one loopback address.",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runLocalhost,
+}
+
+func runLocalhost(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	eval := newStrEvaluator(pass, inspect)
+	check := func(host string, addr ast.Expr) {
+		if !isLocalhostName(host) {
+			return
+		}
+		pass.Reportf(addr.Pos(), "listening on %q binds only the first address it resolves to, ::1 or 127.0.0.1, and clients of the other family are refused; use multilistener.NewLocalLoopback to listen on both", host)
+	}
+
+	forEachListenAddr(pass, inspect, func(api *AddrAPI, call *ast.CallExpr) {
+		if api.Kind != ArgHostPort {
+			return
+		}
+		if host, addr, ok := apiHost(eval, api, call); ok {
+			check(host, addr)
+		}
+	}, func(addr ast.Expr) {
+		if host, _, ok := eval.eval(addr).hostPort(); ok {
+			check(host, addr)
+		}
+	})
+	return nil, nil
+}
+
+// isLocalhostName reports whether host is a name resolving to loopback:
+// "localhost" or a subdomain of it (RFC 6761), with or without the
+// trailing dot
+func isLocalhostName(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return host == "localhost" || strings.HasSuffix(host, ".localhost")
+}
@@ -0,0 +1,220 @@
+package linter
+
+import (
+	"go/ast"
+	"go/token"
+	"net"
+	"net/netip"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// AnalyzerLoopbackString reports loopback and private range checks done on
+// strings, e.g. r.RemoteAddr == "127.0.0.1" or strings.HasPrefix(host,
+// "127.").  They reject ::1 and ::ffff:127.0.0.1, and a security check may
+// be bypassed with another spelling of the same address
+var AnalyzerLoopbackString = &analysis.Analyzer{
+	Name:     "loopbackstring",
+	Doc:      "Reports loopback and private address checks comparing strings instead of using netip.Addr.IsLoopback() and friends.",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runLoopbackString,
+}
+
+// addrClass is the kind of address a string check tests for
+type addrClass struct {
+	name string
+	// method of netip.Addr testing the class
+	method string
+	// other spellings of the class a string check misses
+	misses string
+}
+
+var (
+	classLoopback  = &addrClass{"loopback", "IsLoopback", "::1 and ::ffff:127.0.0.1"}
+	classPrivate   = &addrClass{"private", "IsPrivate", "fd00::/8 and ::ffff:10.0.0.1"}
+	classLinkLocal = &addrClass{"link-local", "IsLinkLocalUnicast", "fe80::/10 and ::ffff:169.254.0.1"}
+)
+
+func runLoopbackString(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	eval := newStrEvaluator(pass, inspect)
+	// checks already reported as part of a || chain
+	seen := make(map[ast.Expr]bool)
+
+	// stringCheck returns the class tested by cond, e.g. host == "::1"
+	stringCheck := func(cond ast.Expr) (*addrClass, string, bool) {
+		switch cond := ast.Unparen(cond).(type) {
+		case *ast.BinaryExpr:
+			if cond.Op != token.EQL && cond.Op != token.NEQ {
+				return nil, "", false
+			}
+			x, lit := cond.X, cond.Y
+			if isConstExpr(pass, x) {
+				x, lit = lit, x
+			}
+			if isConstExpr(pass, x) || !isStringType(pass.TypesInfo.TypeOf(x)) {
+				return nil, "", false
+			}
+			s, ok := eval.constString(lit)
+			if !ok {
+				return nil, "", false
+			}
+			class, ok := classifyAddr(s)
+			return class, s, ok
+		case *ast.CallExpr:
+			fn := typeutil.StaticCallee(pass.TypesInfo, cond)
+			if fn == nil || len(cond.Args) != 2 || isConstExpr(pass, cond.Args[0]) {
+				return nil, "", false
+			}
+			s, ok := eval.constString(cond.Args[1])
+			if !ok {
+				return nil, "", false
+			}
+			switch {
+			case isPkgFunc(fn, "strings", "HasPrefix"):
+				// strings.HasPrefix(host, "127.")
+				class, ok := classifyAddrPrefix(s)
+				return class, s, ok
+			case isPkgFunc(fn, "strings", "EqualFold"):
+				// strings.EqualFold(host, "localhost")
+				class, ok := classifyAddr(s)
+				return class, s, ok
+			}
+		}
+		return nil, "", false
+	}
+	report := func(cond ast.Expr, class *addrClass, s string) {
+		msg := "string comparison with %q misses other spellings of %s addresses such as %s; parse the address and use netip.Addr.%s()"
+		if class == classLoopback {
+			msg += ", or middleware.LocalOnlyMiddleware for HTTP handlers"
+		}
+		pass.Reportf(cond.Pos(), msg, s, class.name, class.misses, class.method)
+	}
+
+	nodeFilter := []ast.Node{
+		(*ast.BinaryExpr)(nil),
+		(*ast.CallExpr)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(node ast.Node) {
+		expr := node.(ast.Expr)
+		bin, chain := expr.(*ast.BinaryExpr)
+		chain = chain && (bin.Op == token.LOR || bin.Op == token.LAND)
+		if chain {
+			// the a || b of (a || b) || c is part of this chain
+			for _, x := range []ast.Expr{bin.X, bin.Y} {
+				if inner, ok := ast.Unparen(x).(*ast.BinaryExpr); ok && inner.Op == bin.Op {
+					seen[inner] = true
+				}
+			}
+		}
+		if seen[expr] {
+			return
+		}
+		// host == "127.0.0.1" || host == "::1" is reported once
+		if chain {
+			var first ast.Expr
+			var class *addrClass
+			var s string
+			for _, op := range logicalOperands(bin, bin.Op) {
+				if c, str, ok := stringCheck(op); ok {
+					seen[ast.Unparen(op)] = true
+					if first == nil {
+						first, class, s = op, c, str
+					}
+				}
+			}
+			if first != nil {
+				report(first, class, s)
+			}
+			return
+		}
+		if class, s, ok := stringCheck(expr); ok {
+			report(expr, class, s)
+		}
+	})
+	return nil, nil
+}
+
+// logicalOperands flattens a || b || c into [a b c] for op ||, or && chains
+// for op &&
+func logicalOperands(expr ast.Expr, op token.Token) []ast.Expr {
+	if b, ok := ast.Unparen(expr).(*ast.BinaryExpr); ok && b.Op == op {
+		return append(logicalOperands(b.X, op), logicalOperands(b.Y, op)...)
+	}
+	return []ast.Expr{expr}
+}
+
+// classifyAddr returns the class of a loopback, private or link-local
+// address literal, with optional brackets and port, or "localhost"
+func classifyAddr(s string) (*addrClass, bool) {
+	host := s
+	if h, _, err := splitHostPortLoose(s); err == nil {
+		host = h
+	}
+	if isLocalhostName(host) {
+		return classLoopback, true
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return nil, false
+	}
+	return classOf(addr)
+}
+
+// classifyAddrPrefix returns the class of the addresses starting with s,
+// e.g. "127." or "192.168.".  The prefix is completed with zeros
+func classifyAddrPrefix(s string) (*addrClass, bool) {
+	if isLocalhostName(s) {
+		return classLoopback, true
+	}
+	if class, ok := classifyAddr(s); ok {
+		return class, true
+	}
+	var full string
+	switch {
+	case strings.HasSuffix(s, "."):
+		parts := strings.Split(strings.TrimSuffix(s, "."), ".")
+		if len(parts) > 3 {
+			return nil, false
+		}
+		for len(parts) < 4 {
+			parts = append(parts, "0")
+		}
+		full = strings.Join(parts, ".")
+	case strings.HasSuffix(s, ":"):
+		full = s + ":"
+	default:
+		return nil, false
+	}
+	addr, err := netip.ParseAddr(full)
+	if err != nil {
+		return nil, false
+	}
+	return classOf(addr)
+}
+
+func classOf(addr netip.Addr) (*addrClass, bool) {
+	addr = addr.Unmap()
+	switch {
+	case addr.IsLoopback():
+		return classLoopback, true
+	case addr.IsPrivate():
+		return classPrivate, true
+	case addr.IsLinkLocalUnicast():
+		return classLinkLocal, true
+	}
+	return nil, false
+}
+
+// splitHostPortLoose splits "host:port" and "[host]:port", and strips the
+// brackets of "[host]"
+func splitHostPortLoose(s string) (string, string, error) {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		return s[1 : len(s)-1], "", nil
+	}
+	return net.SplitHostPort(s)
+}
@@ -7,6 +7,7 @@ import (
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
 )
 
 // The Analyzer's name and description.
@@ -25,8 +26,14 @@ func runParseIP(pass *analysis.Pass) (interface{}, error) {
 	// The key is the variable that holds the result of ParseIP, and the value is the position.
 	parsedIPs := make(map[types.Object]token.Pos)
 
-	// Traverse the AST to find function calls.
-	ast.Inspect(pass.Files[0], func(n ast.Node) bool {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	nodeFilter := []ast.Node{
+		(*ast.AssignStmt)(nil),
+		(*ast.CallExpr)(nil),
+	}
+
+	// Traverse the AST of every file to find function calls.
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
 		// Look for assignment statements that might contain a call to net.ParseIP.
 		if assign, ok := n.(*ast.AssignStmt); ok {
 			// Check if the right-hand side is a function call.
@@ -36,7 +43,7 @@ func runParseIP(pass *analysis.Pass) (interface{}, error) {
 					// We need to check if the function is `net.ParseIP` specifically.
 					// This requires type information, which we get from the analysis.Pass.
 					obj := pass.TypesInfo.ObjectOf(sel.Sel)
-					if obj != nil && obj.Pkg().Path() == "net" && obj.Name() == "ParseIP" {
+					if isPkgObject(obj, "net", "ParseIP") {
 						// Store the variable that receives the IP address.
 						if len(assign.Lhs) > 0 {
 							if ident, ok := assign.Lhs[0].(*ast.Ident); ok {
@@ -66,11 +73,9 @@ func runParseIP(pass *analysis.Pass) (interface{}, error) {
 				}
 			}
 		}
-
-		return true
 	})
 
-	// After traversing the entire file, any remaining entries in parsedIPs
+	// After traversing every file, any remaining entries in parsedIPs
 	// represent calls to net.ParseIP without a subsequent IPv4 check.
 	for _, pos := range parsedIPs {
 		pass.Reportf(pos, "call to `net.ParseIP` should be followed by a check for IPv4 or handle IPv6 compatibility")
@@ -0,0 +1,137 @@
+package linter
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// AnalyzerSplitHostPort reports host:port strings split on ":" with the
+// strings package.  "[::1]:8080" splits into "[", "", "1]" and "8080"
+var AnalyzerSplitHostPort = &analysis.Analyzer{
+	Name:     "splithostport",
+	Doc:      "Reports addresses split on \":\" with strings.Split, Index or Cut instead of net.SplitHostPort.",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runSplitHostPort,
+}
+
+// splitFuncs are the strings functions checked, by the index of the separator
+var splitFuncs = map[string]int{
+	"Split":      1,
+	"SplitN":     1,
+	"SplitAfter": 1,
+	"Index":      1,
+	"IndexByte":  1,
+	"IndexRune":  1,
+	"Cut":        1,
+}
+
+const (
+	suggestSplitHostPort = "net.SplitHostPort or netip.ParseAddrPort"
+	suggestHostname      = "url.URL.Hostname() and Port()"
+)
+
+func runSplitHostPort(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	eval := newStrEvaluator(pass, inspect)
+	nodeFilter := []ast.Node{
+		(*ast.CallExpr)(nil),
+	}
+
+	// string variables passed as "host:port" to listen or dial calls
+	netAddrVars := make(map[types.Object]string)
+	inspect.Preorder(nodeFilter, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		fn := typeutil.StaticCallee(pass.TypesInfo, call)
+		api := matchAPI(fn, ListenAPIs)
+		if api == nil {
+			api = matchAPI(fn, DialAPIs)
+		}
+		if api == nil || api.Kind != ArgHostPort || api.Arg >= len(call.Args) {
+			return
+		}
+		if ident, ok := ast.Unparen(call.Args[api.Arg]).(*ast.Ident); ok {
+			if obj := pass.TypesInfo.ObjectOf(ident); obj != nil {
+				netAddrVars[obj] = funcName(fn)
+			}
+		}
+	})
+
+	inspect.Preorder(nodeFilter, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		fn := typeutil.StaticCallee(pass.TypesInfo, call)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != "strings" {
+			return
+		}
+		sepIndex, ok := splitFuncs[fn.Name()]
+		if !ok || sepIndex >= len(call.Args) || !isColon(pass, call.Args[sepIndex]) {
+			return
+		}
+		source, suggest := addrSource(pass, eval, call.Args[0], netAddrVars)
+		if source == "" {
+			return
+		}
+		pass.Reportf(call.Pos(), "strings.%s on \":\" of %s breaks on IPv6 addresses like \"[::1]:8080\"; use %s", fn.Name(), source, suggest)
+	})
+	return nil, nil
+}
+
+// isColon reports whether expr is the constant ":" or ':'
+func isColon(pass *analysis.Pass, expr ast.Expr) bool {
+	tv, ok := pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil {
+		return false
+	}
+	return tv.Value.ExactString() == `":"` || tv.Value.ExactString() == "58"
+}
+
+// addrSource describes where the string expr came from when it holds a
+// host:port address, with the suggested replacement.  Empty if unknown
+func addrSource(pass *analysis.Pass, eval *strEvaluator, expr ast.Expr, netAddrVars map[types.Object]string) (source, suggest string) {
+	if ident, ok := ast.Unparen(expr).(*ast.Ident); ok {
+		if api, ok := netAddrVars[pass.TypesInfo.ObjectOf(ident)]; ok {
+			return "an address passed to " + api, suggestSplitHostPort
+		}
+	}
+	switch expr := eval.resolve(expr).(type) {
+	case *ast.SelectorExpr:
+		// r.RemoteAddr, r.Host, u.Host
+		selection := pass.TypesInfo.Selections[expr]
+		if selection == nil || selection.Kind() != types.FieldVal {
+			return "", ""
+		}
+		recv := derefType(selection.Recv())
+		switch {
+		case isNamedType(recv, "net/http", "Request") && expr.Sel.Name == "RemoteAddr":
+			return "Request.RemoteAddr", suggestSplitHostPort
+		case isNamedType(recv, "net/http", "Request") && expr.Sel.Name == "Host":
+			return "Request.Host", "net.SplitHostPort"
+		case isNamedType(recv, "net/url", "URL") && expr.Sel.Name == "Host":
+			return "url.URL.Host", suggestHostname
+		}
+	case *ast.CallExpr:
+		// conn.RemoteAddr().String(), l.Addr().String()
+		sel, ok := ast.Unparen(expr.Fun).(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "String" || len(expr.Args) != 0 {
+			return "", ""
+		}
+		if isNetAddrType(pass.TypesInfo.TypeOf(sel.X)) {
+			return "net.Addr.String()", suggestSplitHostPort
+		}
+	}
+	return "", ""
+}
+
+// isNetAddrType reports whether t is net.Addr or one of the net address
+// types formatted as host:port
+func isNetAddrType(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	t = derefType(t)
+	return isNamedType(t, "net", "Addr") || isNamedType(t, "net", "TCPAddr") || isNamedType(t, "net", "UDPAddr")
+}
@@ -0,0 +1,11 @@
+package linter
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestSplitHostPort(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData()+"/splithostport", AnalyzerSplitHostPort)
+}
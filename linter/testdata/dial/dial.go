@@ -0,0 +1,54 @@
+package dial
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const dbAddr = "127.0.0.1:5432"
+
+func dials(port string) {
+	net.Dial("tcp", "127.0.0.1:5432")                    // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+	net.Dial("tcp", dbAddr)                              // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+	net.DialTimeout("tcp", "10.0.0.5:5432", time.Second) // want `dial to hardcoded IPv4 address '10.0.0.5' fails on IPv6-only hosts`
+	net.Dial("tcp", "localhost:5432")
+	net.Dial("tcp", "[::1]:5432")
+	net.Dial("unix", "127.0.0.1:5432")
+
+	d := &net.Dialer{}
+	d.DialContext(context.Background(), "tcp", "127.0.0.1:"+port)                 // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+	(&net.Dialer{}).DialContext(context.Background(), "tcp", "127.0.0.1:5432")    // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+	tls.Dial("tcp", "127.0.0.1:443", nil)                                         // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+	net.DialTCP("tcp", nil, &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5432}) // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+}
+
+func urls(c *http.Client, port string) {
+	http.Get("http://127.0.0.1:8080/health")         // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+	http.Get("http://127.0.0.1/health")              // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+	http.Get("http://127.0.0.1:" + port + "/")       // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+	http.Get("http://user@10.1.1.1:80")              // want `dial to hardcoded IPv4 address '10.1.1.1'`
+	c.Get("https://127.0.0.1/")                      // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+	http.NewRequest("GET", "http://127.0.0.1/", nil) // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+	http.Get("http://127.0.0.1" + port)              // host may continue, unknown
+	http.Get("http://localhost:8080/")
+	http.Get("http://[::1]:8080/")
+}
+
+func targets() {
+	grpc.Dial("127.0.0.1:50051")                                  // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+	grpc.NewClient("dns:///127.0.0.1:50051")                      // want `dial to hardcoded IPv4 loopback address '127.0.0.1'`
+	grpc.DialContext(context.Background(), "ipv4:10.0.0.1:50051") // want `dial to hardcoded IPv4 address '10.0.0.1'`
+	grpc.NewClient("passthrough:///[::1]:50051")
+	grpc.NewClient("unix:///tmp/grpc.sock")
+}
+
+// funcValues are called through func values, which have no static callee
+func funcValues(get func() string) {
+	net.Dial("tcp", get())
+	http.Get("http://" + get() + "/")
+}
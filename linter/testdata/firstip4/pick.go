@@ -0,0 +1,86 @@
+package firstip4
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+)
+
+func localIP() (net.IP, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addrs { // want `loop over net.InterfaceAddrs\(\) returns the first IPv4 address and skips the host's IPv6 addresses; return the addresses of both families, or choose one by RFC 6724 preference`
+		if ipnet, ok := a.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
+			if ipnet.IP.To4() != nil {
+				return ipnet.IP, nil
+			}
+		}
+	}
+	return nil, errors.New("no address")
+}
+
+func byInterface() string {
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		addrs, _ := iface.Addrs()
+		for _, a := range addrs { // want `loop over iface.Addrs\(\) returns the first IPv4 address`
+			ip, _, _ := net.ParseCIDR(a.String())
+			if ip.To4() == nil {
+				continue
+			}
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+func direct() netip.Addr {
+	for _, a := range must(net.InterfaceAddrs()) {
+		_ = a
+	}
+	addrs, _ := net.InterfaceAddrs()
+	for _, a := range addrs { // want `loop over net.InterfaceAddrs\(\) returns the first IPv4 address`
+		addr, ok := netip.AddrFromSlice(a.(*net.IPNet).IP)
+		if ok && addr.Is4() {
+			return addr
+		}
+	}
+	return netip.Addr{}
+}
+
+func must(addrs []net.Addr, err error) []net.Addr { return addrs }
+
+// allAddrs collects both families
+func allAddrs() []net.IP {
+	var ips []net.IP
+	addrs, _ := net.InterfaceAddrs()
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok {
+			ips = append(ips, ipnet.IP)
+		}
+	}
+	return ips
+}
+
+// countV4 looks at every address, without returning early
+func countV4() int {
+	n := 0
+	addrs, _ := net.InterfaceAddrs()
+	for _, a := range addrs {
+		if a.(*net.IPNet).IP.To4() != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func otherLoop(ips []net.IP) net.IP {
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip
+		}
+	}
+	return nil
+}
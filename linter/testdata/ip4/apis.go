@@ -0,0 +1,44 @@
+package ip4
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+)
+
+func entryPoints(h http.Handler, cfg *tls.Config) {
+	http.ListenAndServe("127.0.0.1:8080", h)              // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	http.ListenAndServeTLS("127.0.0.1:8443", "c", "k", h) // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	tls.Listen("tcp", "127.0.0.1:8443", cfg)              // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	net.ListenPacket("udp", "127.0.0.1:53")               // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	net.ListenPacket("unixgram", "127.0.0.1:53")          // unix socket path
+	http.ListenAndServe(":8080", h)                       // dual-stack wildcard
+
+	var lc net.ListenConfig
+	lc.Listen(context.Background(), "tcp", "127.0.0.1:8080")       // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	lc.ListenPacket(context.Background(), "udp", "127.0.0.1:8080") // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	(&net.ListenConfig{}).Listen(context.Background(), "tcp", "[::1]:8080")
+
+	net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}) // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("10.0.0.1")})            // want `found hardcoded IPv4 address '10.0.0.1'`
+	net.ListenTCP("tcp", &net.TCPAddr{IP: net.IP{127, 0, 0, 1}})               // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv6loopback})
+	net.ListenTCP("tcp", &net.TCPAddr{Port: 8080})
+	udpAddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53}
+	net.ListenUDP("udp", udpAddr) // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+}
+
+func servers(h http.Handler) {
+	_ = &http.Server{Addr: "127.0.0.1:8080", Handler: h} // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	_ = http.Server{Addr: ":8080"}
+
+	srv := &http.Server{Handler: h}
+	srv.Addr = "127.0.0.1:8080" // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+}
+
+// ipFuncs builds IPs through func values, which are not net.IPv4 or net.ParseIP
+func ipFuncs(ipv4 func(a, b, c, d byte) net.IP, parse func(string) net.IP) {
+	net.ListenTCP("tcp", &net.TCPAddr{IP: ipv4(127, 0, 0, 1)})
+	net.ListenTCP("tcp", &net.TCPAddr{IP: parse("127.0.0.1")})
+}
@@ -0,0 +1,75 @@
+package ip4
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+const addr = "127.0.0.1:8080"
+
+const (
+	loopback = "127.0.0.1"
+	portNum  = 8080
+)
+
+var unexportedHost = "10.0.0.1"
+
+var ExportedHost = "127.0.0.1" // may be changed by another package
+
+func propagated(port string) {
+	net.Listen("tcp", addr)                             // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	net.Listen("tcp", loopback+":8080")                 // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	net.Listen("tcp", unexportedHost+":"+port)          // want `found hardcoded IPv4 address '10.0.0.1'`
+	net.Listen("tcp", ExportedHost+":"+port)            // exported, unknown
+	net.Listen("tcp", net.JoinHostPort(loopback, port)) // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+
+	host := "127.0.0.1"
+	net.Listen("tcp", host+":"+port) // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+
+	listenAddr := fmt.Sprintf("%s:%d", host, portNum)
+	net.Listen("tcp", listenAddr) // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+
+	net.Listen("tcp", fmt.Sprintf("%s:%s", host, port)) // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	net.Listen("tcp", fmt.Sprintf("%s:%s", port, host)) // unknown host
+}
+
+func reassigned(port string) {
+	host := "127.0.0.1"
+	if h := os.Getenv("HOST"); h != "" {
+		host = h
+	}
+	net.Listen("tcp", host+":"+port) // host may be anything
+
+	dual := "::1"
+	net.Listen("tcp", "["+dual+"]:"+port) // ipv6
+
+	var addrPtr = "127.0.0.1:80"
+	p := &addrPtr
+	_ = p
+	net.Listen("tcp", addrPtr) // address taken, may change
+
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	addr.IP = net.IPv6loopback
+	net.ListenTCP("tcp", addr) // field reassigned
+
+	udp := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	setIP(&udp.IP)
+	net.ListenUDP("udp", udp) // field address taken, may change
+
+	tcp := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	tcp.IP[0] = 10
+	net.ListenTCP("tcp", tcp) // element reassigned
+}
+
+func setIP(ip *net.IP) {
+	*ip = net.IPv6loopback
+}
+
+// funcValues are called through func values, which have no static callee
+func funcValues(get func() string, port func() string) {
+	net.Listen("tcp", get())
+	net.Listen("tcp", get()+":"+port())
+	net.Listen("tcp", "127.0.0.1:"+port()) // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP(get())})
+}
@@ -0,0 +1,29 @@
+package ip4
+
+import (
+	"net"
+	. "net"
+	stdnet "net"
+)
+
+func listeners() {
+	net.Listen("tcp", "127.0.0.1:8080")          // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	stdnet.Listen("tcp", "127.0.0.1:8080")       // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	Listen("tcp", "127.0.0.1:8080")              // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	net.Listen("tcp", "10.1.2.3:8080")           // want `found hardcoded IPv4 address '10.1.2.3'`
+	net.Listen("tcp", "[::ffff:127.0.0.1]:8080") // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	net.Listen("tcp", `127.0.0.1:8080`)          // want `found hardcoded IPv4 loopback address '127.0.0.1'`
+	net.Listen("tcp", "localhost.example:80")    // hostname, not an IPv4 literal
+	net.Listen("tcp", "[::1]:8080")              // ipv6
+	net.Listen("tcp", ":8080")                   // dual-stack wildcard
+	net.Listen("tcp", "127.0.0.1")               // missing port, fails at runtime anyway
+}
+
+type fake struct{}
+
+func (fake) Listen(network, address string) {}
+
+func notNet() {
+	var net fake
+	net.Listen("tcp", "127.0.0.1:8080") // a local named net is not package net
+}
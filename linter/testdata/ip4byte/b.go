@@ -0,0 +1,18 @@
+package a
+
+import (
+	"errors"
+	"net"
+)
+
+// violations outside the first file of the package are reported too
+
+func secondFileSlice(ip net.IP) net.IP {
+	return ip[0:4] // want "fixed-length slice of 4 on a net.IP variable may fail with IPv6"
+}
+
+func secondFileIndex() (byte, error) {
+	err := errors.New("unused")
+	ip := net.ParseIP("10.0.0.1")
+	return ip[3], err // want "fixed index on a net.IP variable may be an IPv4 assumption"
+}
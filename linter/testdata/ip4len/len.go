@@ -0,0 +1,95 @@
+package ip4len
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+func lengths(ip net.IP) bool {
+	if len(ip) == 4 { // want `len\(ip\) compared to 4: net.IP holds IPv4 addresses in 16 bytes too; use ip.To4\(\) != nil`
+		return true
+	}
+	return len(ip) != net.IPv4len // want `len\(ip\) compared to 4`
+}
+
+func conversions(ip net.IP, v uint32) uint32 {
+	buf := make(net.IP, 4)        // want `4-byte net.IP cannot hold an IPv6 address`
+	_ = make(net.IP, net.IPv4len) // want `4-byte net.IP cannot hold an IPv6 address`
+	_ = make(net.IP, net.IPv6len)
+	binary.BigEndian.PutUint32(buf, v) // buf is 4 bytes, its make is reported
+	binary.BigEndian.PutUint32(ip, v)  // want `PutUint32 into net.IP ip writes the first 4 of 16 bytes; write into ip4 := ip.To4\(\)`
+	copy(ip[12:], buf)                 // want `copy into ip\[12:\] assumes the IPv4-in-IPv6 layout`
+	return binary.BigEndian.Uint32(ip) // want `Uint32 of net.IP ip reads the first 4 of 16 bytes; use ip4 := ip.To4\(\)`
+}
+
+// To4() != nil proves ip is IPv4, not that it is 4 bytes long:
+// net.ParseIP("1.2.3.4") is 16 bytes
+func guarded(ip net.IP) uint32 {
+	if ip.To4() != nil {
+		_ = len(ip) == 4                   // want `len\(ip\) compared to 4`
+		return binary.BigEndian.Uint32(ip) // want `Uint32 of net.IP ip reads the first 4 of 16 bytes; use ip4 := ip.To4\(\) and check ip4 != nil`
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		_ = len(ip4) == 4
+		return binary.LittleEndian.Uint32(ip4)
+	}
+	if ip.To4() == nil || len(ip) == 4 { // want `len\(ip\) compared to 4`
+		return 0
+	}
+	return binary.BigEndian.Uint32(ip) // want `Uint32 of net.IP ip`
+}
+
+func earlyReturn(ip net.IP) uint32 {
+	if ip.To4() == nil {
+		return 0
+	}
+	copy(ip[12:], ip)                  // want `copy into ip\[12:\] assumes the IPv4-in-IPv6 layout`
+	return binary.BigEndian.Uint32(ip) // want `Uint32 of net.IP ip`
+}
+
+func elseBranch(ip net.IP) uint32 {
+	if ip.To4() == nil {
+		return 1
+	} else {
+		return binary.BigEndian.Uint32(ip) // want `Uint32 of net.IP ip`
+	}
+}
+
+// lengthChecked only converts 4-byte values; the check itself is reported
+func lengthChecked(ip net.IP) uint32 {
+	if len(ip) != net.IPv4len { // want `len\(ip\) compared to 4`
+		return 0
+	}
+	return binary.BigEndian.Uint32(ip)
+}
+
+func reassigned(ip net.IP) uint32 {
+	ip = ip.To4()
+	if ip == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(ip)
+}
+
+func reassignedInit(ip net.IP) uint32 {
+	if ip = ip.To4(); ip != nil {
+		return binary.BigEndian.Uint32(ip)
+	}
+	return 0
+}
+
+func reassignedAgain(ip, other net.IP) uint32 {
+	ip = ip.To4()
+	if ip == nil {
+		return 0
+	}
+	ip = other
+	return binary.BigEndian.Uint32(ip) // want `Uint32 of net.IP ip`
+}
+
+func otherValue(ip, other net.IP) uint32 {
+	if other.To4() != nil {
+		return binary.BigEndian.Uint32(ip) // want `Uint32 of net.IP ip`
+	}
+	return 0
+}
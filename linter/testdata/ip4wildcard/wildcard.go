@@ -0,0 +1,54 @@
+package ip4wildcard
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+)
+
+const anyAddr = "0.0.0.0:8080"
+
+func strings(h http.Handler, port string, n int) {
+	net.Listen("tcp", "0.0.0.0:8080")               // want `listening on 0.0.0.0 exposes the service on all IPv4 interfaces but none of the IPv6 ones; use ":PORT" to listen on both`
+	net.Listen("tcp", "0.0.0.0:"+port)              // want `listening on 0.0.0.0 exposes the service on all IPv4 interfaces`
+	net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", n)) // want `listening on 0.0.0.0`
+	net.ListenPacket("udp", "[::ffff:0.0.0.0]:53")  // want `listening on 0.0.0.0`
+	http.ListenAndServe(anyAddr, h)                 // want `listening on 0.0.0.0`
+	net.Listen("tcp", ":8080")
+	net.Listen("tcp", "[::]:8080")
+	net.Listen("tcp", "127.0.0.1:8080")
+	net.Listen("unix", "0.0.0.0:8080")
+
+	addr := "0.0.0.0:8443"
+	net.Listen("tcp", addr) // want `listening on 0.0.0.0`
+
+	// no fix: editing the definition would change the Dial too
+	shared := "0.0.0.0:9090"
+	net.Listen("tcp", shared) // want `listening on 0.0.0.0`
+	net.Dial("tcp", shared)
+}
+
+func ipAddrs() {
+	net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4zero, Port: 8080})       // want `listening on 0.0.0.0`
+	net.ListenUDP("udp", &net.UDPAddr{Port: 53, IP: net.IPv4(0, 0, 0, 0)}) // want `listening on 0.0.0.0`
+	net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("0.0.0.0")})         // want `listening on 0.0.0.0`
+	net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv6unspecified, Port: 8080})
+	net.ListenTCP("tcp", &net.TCPAddr{Port: 8080})
+
+	// no fix: editing the definition would change the Dial too
+	taddr := &net.TCPAddr{IP: net.IPv4zero, Port: 8080}
+	net.ListenTCP("tcp", taddr) // want `listening on 0.0.0.0`
+	net.DialTCP("tcp", nil, taddr)
+}
+
+func servers(h http.Handler) {
+	_ = &http.Server{Addr: "0.0.0.0:8080", Handler: h} // want `listening on 0.0.0.0`
+	srv := &http.Server{Handler: h}
+	srv.Addr = "0.0.0.0:8080" // want `listening on 0.0.0.0`
+}
+
+func funcValues(get func() string, ip func() net.IP) {
+	net.Listen("tcp", get())
+	net.Listen("tcp", "0.0.0.0:"+get()) // want `listening on 0.0.0.0`
+	net.ListenTCP("tcp", &net.TCPAddr{IP: ip()})
+}
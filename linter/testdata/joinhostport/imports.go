@@ -0,0 +1,10 @@
+package joinhostport
+
+import (
+	"net/http"
+)
+
+// the fix adds the net import
+func noNetImport(host, port string) {
+	http.Get("http://" + host + ":" + port) // want `host:port built with string concatenation is invalid for IPv6 hosts`
+}
@@ -0,0 +1,54 @@
+package joinhostport
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+func sprintf(host string, port int, sport string, ip net.IP, p16 uint16) {
+	net.Listen("tcp", fmt.Sprintf("%s:%d", host, port))      // want `host:port built with fmt.Sprintf is invalid for IPv6 hosts`
+	net.Dial("tcp", fmt.Sprintf("%s:%s", host, sport))       // want `host:port built with fmt.Sprintf is invalid for IPv6 hosts`
+	net.Dial("tcp", fmt.Sprintf("%v:%d", ip, p16))           // want `host:port built with fmt.Sprintf is invalid for IPv6 hosts`
+	net.Dial("tcp", fmt.Sprintf("%s:8080", host))            // want `host:port built with fmt.Sprintf is invalid for IPv6 hosts`
+	http.Get(fmt.Sprintf("http://%s:%d/health", host, port)) // want `host:port built with fmt.Sprintf is invalid for IPv6 hosts`
+
+	addr := fmt.Sprintf("%s:%d", host, port) // want `host:port built with fmt.Sprintf is invalid for IPv6 hosts`
+	net.Listen("tcp", addr)
+	net.Dial("tcp", addr) // reported once, at the definition
+
+	http.Get(fmt.Sprintf("http://%s:%d/", ip, port)) // URL with an IP host, see AnalyzerURLHost
+
+	net.Dial("tcp", fmt.Sprintf("[%s]:%d", host, port))      // bracketed by hand
+	net.Dial("tcp", fmt.Sprintf("%s:%d", "localhost", port)) // known hostname
+	net.Dial("tcp", net.JoinHostPort(host, sport))
+}
+
+func concat(host, port string) {
+	net.Listen("tcp", host+":"+port)                     // want `host:port built with string concatenation is invalid for IPv6 hosts`
+	net.Dial("tcp", host+":8080")                        // want `host:port built with string concatenation is invalid for IPv6 hosts`
+	http.Get("http://" + host + ":" + port + "/x")       // want `host:port built with string concatenation is invalid for IPv6 hosts`
+	http.Get("http://" + host + ":8080/x")               // want `host:port built with string concatenation is invalid for IPv6 hosts`
+	_ = url.URL{Scheme: "http", Host: host + ":" + port} // want `host:port built with string concatenation is invalid for IPv6 hosts`
+
+	srv := &http.Server{}
+	srv.Addr = host + ":" + port // want `host:port built with string concatenation is invalid for IPv6 hosts`
+
+	net.Dial("tcp", "["+host+"]:"+port)
+	local := "localhost"
+	net.Dial("tcp", local+":"+port)
+	net.Dial("tcp", ":"+port)
+}
+
+func unsupported(host string, port int, sprintf func(string, ...any) string) {
+	net.Dial("tcp", fmt.Sprintf("%*s:%d", 10, host, port))  // * width takes an argument
+	net.Dial("tcp", fmt.Sprintf("%[2]s:%[1]d", port, host)) // explicit argument index
+	net.Dial("tcp", sprintf("%s:%d", host, port))           // func value
+}
+
+// baseURLs already hold a scheme and host, the ":" starts their port
+func baseURLs(baseURL, port string, iport int) {
+	http.Get(baseURL + ":" + port + "/health")
+	http.Get(fmt.Sprintf("%s:%d/health", baseURL, iport))
+}
@@ -0,0 +1,30 @@
+package localhost
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+const devAddr = "localhost:8080"
+
+func listen(h http.Handler, port string) {
+	net.Listen("tcp", "localhost:8080")         // want `listening on "localhost" binds only the first address it resolves to, ::1 or 127.0.0.1, and clients of the other family are refused; use multilistener.NewLocalLoopback to listen on both`
+	net.Listen("tcp", "localhost:"+port)        // want `listening on "localhost" binds only the first address`
+	net.ListenPacket("udp", "LocalHost.:53")    // want `listening on "LocalHost." binds only the first address`
+	net.Listen("tcp", "api.localhost:8080")     // want `listening on "api.localhost" binds only the first address`
+	http.ListenAndServe(devAddr, h)             // want `listening on "localhost" binds only the first address`
+	net.Listen("unix", "localhost:8080")        // unix socket path
+	net.Listen("tcp", "localhost.example:8080") // not loopback
+	net.Listen("tcp", "[::1]:8080")
+	net.Dial("tcp", "localhost:8080") // dialing tries every address
+
+	var lc net.ListenConfig
+	lc.Listen(context.Background(), "tcp", "localhost:0") // want `listening on "localhost" binds only the first address`
+}
+
+func servers(h http.Handler) {
+	_ = &http.Server{Addr: "localhost:8080", Handler: h} // want `listening on "localhost" binds only the first address`
+	srv := &http.Server{Handler: h}
+	srv.Addr = "localhost:8080" // want `listening on "localhost" binds only the first address`
+}
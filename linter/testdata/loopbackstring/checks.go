@@ -0,0 +1,45 @@
+package loopbackstring
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+const local = "127.0.0.1"
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	if r.RemoteAddr == "127.0.0.1" { // want `string comparison with "127.0.0.1" misses other spellings of loopback addresses such as ::1 and ::ffff:127.0.0.1; parse the address and use netip.Addr.IsLoopback\(\), or middleware.LocalOnlyMiddleware for HTTP handlers`
+		return
+	}
+	host, _, _ := net.SplitHostPort(r.RemoteAddr)
+	if host != local { // want `string comparison with "127.0.0.1" misses other spellings of loopback addresses`
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}
+	if host == "127.0.0.1" || host == "::1" || host == "[::1]" || host == "localhost" { // want `string comparison with "127.0.0.1" misses`
+		return
+	}
+	if "localhost" == r.Host { // want `string comparison with "localhost" misses other spellings of loopback addresses`
+		return
+	}
+	_ = strings.EqualFold(host, "LocalHost") // want `string comparison with "LocalHost" misses`
+	_ = r.RemoteAddr == "127.0.0.1:8080"     // want `string comparison with "127.0.0.1:8080" misses`
+	_ = host == "example.com" || host == "8.8.8.8"
+	_ = local == "127.0.0.1"
+}
+
+func prefixes(host string, ip net.IP) bool {
+	_ = strings.HasPrefix(host, "127.")     // want `string comparison with "127." misses other spellings of loopback addresses`
+	_ = strings.HasPrefix(host, "192.168.") // want `string comparison with "192.168." misses other spellings of private addresses such as fd00::/8 and ::ffff:10.0.0.1; parse the address and use netip.Addr.IsPrivate\(\)$`
+	_ = strings.HasPrefix(host, "10.")      // want `string comparison with "10." misses other spellings of private addresses`
+	_ = strings.HasPrefix(host, "169.254.") // want `string comparison with "169.254." misses other spellings of link-local addresses such as fe80::/10 and ::ffff:169.254.0.1; parse the address and use netip.Addr.IsLinkLocalUnicast\(\)`
+	_ = strings.HasPrefix(host, "fe80:")    // want `string comparison with "fe80:" misses other spellings of link-local addresses`
+	_ = strings.HasPrefix(host, "172.")
+	_ = strings.HasPrefix(host, "http://")
+	_ = strings.HasPrefix(host, "1")
+	return ip.String() == "127.0.0.1" // want `string comparison with "127.0.0.1" misses`
+}
+
+func funcValues(host string, check func(string, string) bool) bool {
+	return check(host, "127.") || check(host, "localhost")
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// violations outside the first file of the package are reported too
+
+func SecondFile() {
+	ip := net.ParseIP("10.0.0.1") // want "call to `net.ParseIP` should be followed by a check for IPv4 or handle IPv6 compatibility"
+	msg := errors.New("universe methods have no package").Error()
+	fmt.Println(ip.String(), msg)
+}
@@ -0,0 +1,36 @@
+package splithostport
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	_ = strings.Split(r.RemoteAddr, ":")[0] // want `strings.Split on ":" of Request.RemoteAddr breaks on IPv6 addresses like "\[::1\]:8080"; use net.SplitHostPort or netip.ParseAddrPort`
+	_ = strings.Index(r.RemoteAddr, ":")    // want `strings.Index on ":" of Request.RemoteAddr`
+	_ = strings.IndexByte(r.Host, ':')      // want `strings.IndexByte on ":" of Request.Host breaks on IPv6 addresses like "\[::1\]:8080"; use net.SplitHostPort`
+	remote := r.RemoteAddr
+	host, _, _ := strings.Cut(remote, ":") // want `strings.Cut on ":" of Request.RemoteAddr`
+	_ = host
+
+	_ = strings.Split(r.URL.Path, ":") // not an address
+	_ = strings.Split(r.RemoteAddr, ",")
+	_ = strings.LastIndex(r.RemoteAddr, ":")
+}
+
+func addrs(l net.Listener, c net.Conn, u *url.URL) {
+	_ = strings.Split(l.Addr().String(), ":")           // want `strings.Split on ":" of net.Addr.String\(\)`
+	_ = strings.SplitN(c.RemoteAddr().String(), ":", 2) // want `strings.SplitN on ":" of net.Addr.String\(\)`
+	_ = strings.Split(u.Host, ":")                      // want `strings.Split on ":" of url.URL.Host breaks on IPv6 addresses like "\[::1\]:8080"; use url.URL.Hostname\(\) and Port\(\)`
+	_ = u.Hostname()
+}
+
+func dialed(addr string) {
+	net.Dial("tcp", addr)
+	_ = strings.Split(addr, ":") // want `strings.Split on ":" of an address passed to net.Dial`
+
+	other := "a:b:c"
+	_ = strings.Split(other, ":")
+}
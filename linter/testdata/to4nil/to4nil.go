@@ -0,0 +1,65 @@
+package to4nil
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+)
+
+func direct(ip net.IP) uint32 {
+	_ = ip.To4()[0]                          // want `ip.To4\(\) is nil when ip is IPv6; check ip.To4\(\) != nil before indexing it`
+	_ = ip.To4()[:2]                         // want `ip.To4\(\) is nil when ip is IPv6; check ip.To4\(\) != nil before slicing it`
+	_ = [4]byte(ip.To4())                    // want `before converting it to \[4\]byte`
+	return binary.BigEndian.Uint32(ip.To4()) // want `before passing it to Uint32`
+}
+
+func variable(ip net.IP, v uint32) byte {
+	ip4 := ip.To4()
+	binary.BigEndian.PutUint32(ip4, v) // want `ip4 is nil when ip is IPv6; check ip4 != nil before passing it to PutUint32`
+	return ip4[3]                      // want `ip4 is nil when ip is IPv6`
+}
+
+func guarded(ip net.IP) uint32 {
+	if ip4 := ip.To4(); ip4 != nil {
+		return binary.BigEndian.Uint32(ip4)
+	}
+	if ip.To4() != nil {
+		return uint32(ip.To4()[0])
+	}
+	ip4 := ip.To4()
+	if ip4 == nil || ip4[0] == 0 {
+		return 0
+	}
+	if len(ip4) == net.IPv4len {
+		_ = [4]byte(ip4)
+	}
+	return binary.BigEndian.Uint32(ip4)
+}
+
+func otherValue(ip, other net.IP) byte {
+	if other.To4() != nil {
+		return ip.To4()[0] // want `ip.To4\(\) is nil when ip is IPv6`
+	}
+	ip4 := ip.To4()
+	for range ip4 {
+	}
+	return 0
+}
+
+func as4(addr netip.Addr) [4]byte {
+	if addr.Is4() {
+		return addr.As4()
+	}
+	if addr.Is4In6() {
+		return addr.As4()
+	}
+	if !addr.Is4() {
+		_ = addr.As16()
+		return addr.As4() // want `addr.As4\(\) panics when addr is IPv6; check addr.Is4\(\) or addr.Is4In6\(\) first`
+	}
+	return addr.As4()
+}
+
+func as4Unchecked(addr netip.Addr) [4]byte {
+	return addr.As4() // want `addr.As4\(\) panics when addr is IPv6`
+}
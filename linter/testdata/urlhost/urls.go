@@ -0,0 +1,46 @@
+package urlhost
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+)
+
+func concat(ip net.IP, port string, n int) {
+	_ = "http://" + ip.String() + ":" + port            // want `URL host ip.String\(\) is not bracketed when it is an IPv6 address \("http://::1:80/"\); use net.JoinHostPort`
+	_ = "https://" + ip.String() + ":8443/health"       // want `URL host ip.String\(\) is not bracketed`
+	_ = "https://" + ip.String() + "/health"            // want `URL host ip.String\(\) is not bracketed`
+	_ = "grpc://" + ip.String()                         // want `URL host ip.String\(\) is not bracketed`
+	http.Get("http://" + ip.String() + ":" + port)      // want `URL host ip.String\(\) is not bracketed`
+	_ = "http://" + net.JoinHostPort(ip.String(), port) // joined
+	_ = "http://[" + ip.String() + "]:" + port          // bracketed by hand
+	_ = "ip=" + ip.String()
+}
+
+func sprintf(addr netip.Addr, r *http.Request, port int) {
+	host, _, _ := net.SplitHostPort(r.Host)
+	_ = fmt.Sprintf("https://%s/", host)             // want `URL host host is not bracketed`
+	_ = fmt.Sprintf("http://%s:%d/x", addr, port)    // want `URL host addr is not bracketed`
+	_ = fmt.Sprintf("http://%v:8080", addr.String()) // want `URL host addr.String\(\) is not bracketed`
+	_ = fmt.Sprintf("custom://%s", host)             // want `URL host host is not bracketed`
+	_ = fmt.Sprintf("https://%s/", r.Host)           // Host keeps its brackets
+	_ = fmt.Sprintf("host %s", host)
+	_ = fmt.Sprintf("https://%s/", netip.AddrPortFrom(addr, 443))
+}
+
+func urls(ip net.IP, addr netip.Addr) {
+	_ = url.URL{Scheme: "https", Host: ip.String(), Path: "/"} // want `URL host ip.String\(\) is not bracketed`
+	_ = &url.URL{Scheme: "ftp", Host: addr.String()}           // want `URL host addr.String\(\) is not bracketed`
+	u := &url.URL{Scheme: "http"}
+	u.Host = addr.String() // want `URL host addr.String\(\) is not bracketed`
+	u.Host = net.JoinHostPort(addr.String(), "80")
+}
+
+func funcValues(split func(string) (string, string, error), sprintf func(string, ...any) string, ip net.IP) {
+	host, port, _ := split("[::1]:80")
+	_ = "http://" + host + ":" + port
+	_ = sprintf("http://%s/", ip)
+	_ = fmt.Sprintf("http://%[1]s:%[2]d/", ip, 80) // explicit argument index
+}
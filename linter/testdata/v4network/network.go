@@ -0,0 +1,42 @@
+package v4network
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+const network = "tcp4"
+
+func networks(r *net.Resolver) {
+	net.Listen("tcp4", ":8080")                            // want `network "tcp4" passed to net.Listen opts out of IPv6; use "tcp"`
+	net.Dial(network, "example.com:80")                    // want `network "tcp4" passed to net.Dial opts out of IPv6`
+	net.ListenPacket("udp4", ":53")                        // want `network "udp4" passed to net.ListenPacket opts out of IPv6; use "udp"`
+	net.ListenPacket("ip4:icmp", "0.0.0.0")                // want `network "ip4:icmp" passed to net.ListenPacket opts out of IPv6; use "ip:icmp"`
+	net.ResolveTCPAddr("tcp4", "example.com:80")           // want `network "tcp4" passed to net.ResolveTCPAddr`
+	r.LookupIP(context.Background(), "ip4", "example.com") // want `network "ip4" passed to net.Resolver.LookupIP opts out of IPv6; use "ip"`
+
+	net.Listen("tcp", ":8080")
+	net.Listen("tcp6", ":8080")
+	r.LookupIP(context.Background(), "ip", "example.com")
+}
+
+func transport() *http.Transport {
+	d := &net.Dialer{}
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return d.DialContext(ctx, "tcp4", addr) // want `network "tcp4" passed to net.Dialer.DialContext opts out of IPv6`
+		},
+	}
+}
+
+func allowed() {
+	//ip6check:allow-v4only legacy peer only speaks IPv4
+	net.Dial("tcp4", "legacy.example:80")
+
+	net.Dial("udp4", "legacy.example:53") //ip6check:allow-v4only DHCP is IPv4
+	net.Dial("tcp4", "legacy.example:80") // want `network "tcp4" passed to net.Dial opts out of IPv6`
+
+	//ip6check:allow-v4only // want `//ip6check:allow-v4only needs a reason`
+	net.Dial("tcp4", "legacy.example:80")
+}
@@ -0,0 +1,111 @@
+package linter
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// AnalyzerTo4Nil reports uses of a To4() result that panic or misbehave
+// when the address is IPv6 and To4() returns nil, e.g. ip.To4()[0] or
+// binary.BigEndian.Uint32(ip.To4()), and netip.Addr.As4() calls, which
+// panic on IPv6 addresses
+var AnalyzerTo4Nil = &analysis.Analyzer{
+	Name:     "to4nil",
+	Doc:      "Reports To4() results indexed or converted without a nil check, and netip.Addr.As4() without Is4() or Is4In6().",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runTo4Nil,
+}
+
+func runTo4Nil(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	eval := newStrEvaluator(pass, inspect)
+
+	// check reports x when it is a To4() result no nil check dominates
+	check := func(x ast.Expr, stack []ast.Node, use string) {
+		ip, ok := to4Receiver(eval, x)
+		if !ok || guardedNonNil(stack, x, eval) || guardedV4(stack, ip) {
+			return
+		}
+		pass.Reportf(x.Pos(), "%s is nil when %s is IPv6; check %s != nil before %s", types.ExprString(x), types.ExprString(ip), types.ExprString(x), use)
+	}
+
+	nodeFilter := []ast.Node{
+		(*ast.IndexExpr)(nil),
+		(*ast.SliceExpr)(nil),
+		(*ast.CallExpr)(nil),
+	}
+	inspect.WithStack(nodeFilter, func(node ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		switch node := node.(type) {
+		case *ast.IndexExpr:
+			check(node.X, stack, "indexing it")
+		case *ast.SliceExpr:
+			check(node.X, stack, "slicing it")
+		case *ast.CallExpr:
+			// [4]byte(ip.To4())
+			if tv, ok := pass.TypesInfo.Types[node.Fun]; ok && tv.IsType() && len(node.Args) == 1 {
+				if isByteArray(tv.Type) {
+					check(node.Args[0], stack, "converting it to "+types.ExprString(node.Fun))
+				}
+				return true
+			}
+			fn, _ := typeutil.Callee(pass.TypesInfo, node).(*types.Func)
+			if fn == nil || fn.Pkg() == nil {
+				return true
+			}
+			switch {
+			case fn.Pkg().Path() == "encoding/binary" && (fn.Name() == "Uint32" || fn.Name() == "PutUint32") && len(node.Args) > 0:
+				// binary.BigEndian.Uint32(ip.To4())
+				check(node.Args[0], stack, "passing it to "+fn.Name())
+			case funcName(fn) == "netip.Addr.As4":
+				sel, ok := ast.Unparen(node.Fun).(*ast.SelectorExpr)
+				if ok && !guardedV4(stack, sel.X) {
+					x := types.ExprString(sel.X)
+					pass.Reportf(node.Pos(), "%s.As4() panics when %s is IPv6; check %s.Is4() or %s.Is4In6() first", x, x, x, x)
+				}
+			}
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// guardedNonNil reports whether the node on top of stack only runs when the
+// net.IP x is not nil: inside if x != nil { ... }, after an early
+// if x == nil { return }, or after len(x) == 4
+func guardedNonNil(stack []ast.Node, x ast.Expr, eval *strEvaluator) bool {
+	return guarded(stack, func(cond ast.Expr, want bool) bool {
+		return implies(cond, want, func(atom ast.Expr, want bool) bool {
+			bin, ok := atom.(*ast.BinaryExpr)
+			if !ok {
+				return false
+			}
+			if other, ok := nilComparison(bin); ok && sameValue(other, x) {
+				return want == (bin.Op == token.NEQ)
+			}
+			return isLen4Check(eval, atom, x, want)
+		})
+	})
+}
+
+// isByteArray reports whether t is a byte array or a pointer to one, the
+// targets of slice-to-array conversions
+func isByteArray(t types.Type) bool {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	arr, ok := t.Underlying().(*types.Array)
+	if !ok {
+		return false
+	}
+	elem, ok := arr.Elem().Underlying().(*types.Basic)
+	return ok && elem.Kind() == types.Byte
+}
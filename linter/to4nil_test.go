@@ -0,0 +1,11 @@
+package linter
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestTo4Nil(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData()+"/to4nil", AnalyzerTo4Nil)
+}
@@ -0,0 +1,42 @@
+package linter
+
+import (
+	"go/types"
+)
+
+// isNamedType reports whether t is the named type path.name, e.g. net.IP
+func isNamedType(t types.Type, path, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	return isPkgObject(named.Obj(), path, name)
+}
+
+// isPkgObject reports whether obj is path.name.  Universe objects like the
+// error type have no package and never match
+func isPkgObject(obj types.Object, path, name string) bool {
+	return obj != nil && obj.Pkg() != nil && obj.Pkg().Path() == path && obj.Name() == name
+}
+
+// isPkgFunc reports whether fn is the function path.name.  fn is nil for
+// calls through func values (see typeutil.StaticCallee); checked here, as
+// a nil *types.Func passed to isPkgObject is a non-nil types.Object
+func isPkgFunc(fn *types.Func, path, name string) bool {
+	return fn != nil && isPkgObject(fn, path, name)
+}
+
+// funcName formats fn for messages: "net.Dial", or "net.Dialer.DialContext"
+// for methods
+func funcName(fn *types.Func) string {
+	name := fn.Name()
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		if named, ok := derefType(recv.Type()).(*types.Named); ok {
+			name = named.Obj().Name() + "." + name
+		}
+	}
+	if fn.Pkg() == nil {
+		return name
+	}
+	return fn.Pkg().Name() + "." + name
+}
@@ -0,0 +1,265 @@
+package linter
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// AnalyzerURLHost reports URLs whose host is an IP address without
+// brackets: "http://" + ip.String() + ":" + port, url.URL{Host:
+// ip.String()} or fmt.Sprintf("https://%s/", host) with host from
+// net.SplitHostPort.  With an IPv6 host they produce "http://::1:8080/"
+// instead of "http://[::1]:8080/".  URLs with a port are fixed with
+// net.JoinHostPort
+var AnalyzerURLHost = &analysis.Analyzer{
+	Name:     "urlhost",
+	Doc:      "Reports URLs built from a net.IP, netip.Addr or SplitHostPort host without net.JoinHostPort or brackets.",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runURLHost,
+}
+
+// urlHostMessage is the diagnostic for the unbracketed URL host
+func urlHostMessage(host ast.Expr) string {
+	return "URL host " + types.ExprString(host) + " is not bracketed when it is an IPv6 address (\"http://::1:80/\"); use net.JoinHostPort"
+}
+
+func runURLHost(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	eval := newStrEvaluator(pass, inspect)
+	hosts := newIPHosts(pass, inspect)
+
+	nodeFilter := []ast.Node{
+		(*ast.BinaryExpr)(nil),
+		(*ast.CallExpr)(nil),
+		(*ast.CompositeLit)(nil),
+		(*ast.AssignStmt)(nil),
+	}
+	// outer concatenations cover the inner a + b of a + b + c
+	inner := make(map[ast.Expr]bool)
+	inspect.Preorder(nodeFilter, func(node ast.Node) {
+		switch node := node.(type) {
+		case *ast.BinaryExpr:
+			if node.Op != token.ADD {
+				return
+			}
+			for _, x := range []ast.Expr{node.X, node.Y} {
+				if b, ok := ast.Unparen(x).(*ast.BinaryExpr); ok && b.Op == token.ADD {
+					inner[b] = true
+				}
+			}
+			if inner[node] {
+				return
+			}
+			checkURLConcat(pass, eval, hosts, node)
+		case *ast.CallExpr:
+			if isPkgFunc(typeutil.StaticCallee(pass.TypesInfo, node), "fmt", "Sprintf") && len(node.Args) > 1 {
+				checkURLSprintf(pass, eval, hosts, node)
+			}
+		case *ast.CompositeLit:
+			// url.URL{Scheme: "https", Host: ip.String()}
+			if !isNamedType(pass.TypesInfo.TypeOf(node), "net/url", "URL") {
+				return
+			}
+			for _, elt := range node.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Host" && hosts.isIPHost(kv.Value) {
+					pass.Report(analysis.Diagnostic{Pos: kv.Value.Pos(), End: kv.Value.End(), Message: urlHostMessage(kv.Value)})
+				}
+			}
+		case *ast.AssignStmt:
+			// u.Host = ip.String()
+			if len(node.Lhs) != len(node.Rhs) {
+				return
+			}
+			for i, lhs := range node.Lhs {
+				sel, ok := lhs.(*ast.SelectorExpr)
+				if !ok || sel.Sel.Name != "Host" {
+					continue
+				}
+				selection := pass.TypesInfo.Selections[sel]
+				if selection != nil && selection.Kind() == types.FieldVal &&
+					isNamedType(derefType(selection.Recv()), "net/url", "URL") && hosts.isIPHost(node.Rhs[i]) {
+					pass.Report(analysis.Diagnostic{Pos: node.Rhs[i].Pos(), End: node.Rhs[i].End(), Message: urlHostMessage(node.Rhs[i])})
+				}
+			}
+		}
+	})
+	return nil, nil
+}
+
+// isURLPrefix reports whether prefix ends with a scheme and "://", e.g.
+// "GET https://", so the host of a URL comes next
+func isURLPrefix(prefix string) bool {
+	rest, ok := strings.CutSuffix(prefix, "://")
+	if !ok || rest == "" {
+		return false
+	}
+	c := rest[len(rest)-1]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// checkURLConcat reports "http://" + host + ... with an IP host
+func checkURLConcat(pass *analysis.Pass, eval *strEvaluator, hosts *ipHosts, expr *ast.BinaryExpr) {
+	ops := concatOperands(expr)
+	for i := 1; i < len(ops); i++ {
+		prefix, ok := eval.constString(ops[i-1])
+		if !ok {
+			continue
+		}
+		if !isURLPrefix(prefix) || !hosts.isIPHost(ops[i]) {
+			continue
+		}
+		// without a port there is no fix: brackets are invalid for IPv4, and
+		// an explicit default port changes the URL
+		host := ops[i]
+		pair := hostPortPair{host: host}
+		var rest string
+		end := i
+		if i+1 < len(ops) {
+			if sep, ok := eval.constString(ops[i+1]); ok && strings.HasPrefix(sep, ":") {
+				// "http://" + host + ":" + port
+				end = i + 1
+				if digits := leadingDigits(sep[1:]); digits != "" {
+					pair.portLit, rest = digits, sep[1+len(digits):]
+				} else if sep == ":" && i+2 < len(ops) {
+					pair.port, end = ops[i+2], i+2
+				}
+			}
+		}
+		diag := analysis.Diagnostic{
+			Pos:     host.Pos(),
+			End:     ops[end].End(),
+			Message: urlHostMessage(host),
+		}
+		if file := fileOf(pass, expr.Pos()); file != nil && (pair.port != nil || pair.portLit != "") {
+			if join, edits, ok := joinHostPortText(pass, file, pair); ok {
+				if rest != "" {
+					join += " + " + strconv.Quote(rest)
+				}
+				edits = append(edits, analysis.TextEdit{Pos: host.Pos(), End: ops[end].End(), NewText: []byte(join)})
+				diag.SuggestedFixes = []analysis.SuggestedFix{{Message: "Use net.JoinHostPort", TextEdits: edits}}
+			}
+		}
+		pass.Report(diag)
+		return
+	}
+}
+
+// checkURLSprintf reports fmt.Sprintf("https://%s/", host) with an IP host
+func checkURLSprintf(pass *analysis.Pass, eval *strEvaluator, hosts *ipHosts, call *ast.CallExpr) {
+	format := eval.eval(call.Args[0])
+	if !format.complete {
+		return
+	}
+	f := format.prefix
+	// args are counted one per verb
+	if movesArgs(f) {
+		return
+	}
+	args := call.Args[1:]
+	argIndex := 0
+	for i := 0; i+1 < len(f); i++ {
+		if f[i] != '%' {
+			continue
+		}
+		verb := f[i+1]
+		i++
+		if verb == '%' {
+			continue
+		}
+		index := argIndex
+		argIndex++
+		if (verb != 's' && verb != 'v') || !isURLPrefix(f[:i-1]) || index >= len(args) || !hosts.isIPHost(args[index]) {
+			continue
+		}
+		pair := hostPortPair{host: args[index]}
+		end := i + 1 // after "%s"
+		rest := f[end:]
+		if strings.HasPrefix(rest, ":") {
+			if len(rest) >= 3 && rest[1] == '%' && strings.IndexByte("sdv", rest[2]) >= 0 && index+1 < len(args) {
+				pair.port, pair.portVerb = args[index+1], rest[2]
+				end += 3
+			} else if digits := leadingDigits(rest[1:]); digits != "" {
+				pair.portLit = digits
+				end += 1 + len(digits)
+			}
+		}
+		diag := analysis.Diagnostic{
+			Pos:     call.Pos(),
+			End:     call.End(),
+			Message: urlHostMessage(args[index]),
+		}
+		// only a literal format can be rewritten
+		if _, ok := call.Args[0].(*ast.BasicLit); ok && (pair.port != nil || pair.portLit != "") {
+			diag.SuggestedFixes = sprintfFix(pass, call, f[:i-1]+"%s"+f[end:], index, pair)
+		}
+		pass.Report(diag)
+		return
+	}
+}
+
+// ipHosts finds expressions holding a bare IP address string: a net.IP or
+// netip.Addr, their String(), or the host returned by net.SplitHostPort
+type ipHosts struct {
+	pass *analysis.Pass
+	// splitHosts are the variables assigned the host of net.SplitHostPort
+	splitHosts map[*types.Var]bool
+}
+
+func newIPHosts(pass *analysis.Pass, inspect *inspector.Inspector) *ipHosts {
+	h := &ipHosts{pass: pass, splitHosts: make(map[*types.Var]bool)}
+	nodeFilter := []ast.Node{
+		(*ast.AssignStmt)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		assign := n.(*ast.AssignStmt)
+		if len(assign.Rhs) != 1 || len(assign.Lhs) != 3 {
+			return
+		}
+		call, ok := ast.Unparen(assign.Rhs[0]).(*ast.CallExpr)
+		if !ok || !isPkgFunc(typeutil.StaticCallee(pass.TypesInfo, call), "net", "SplitHostPort") {
+			return
+		}
+		if ident, ok := assign.Lhs[0].(*ast.Ident); ok {
+			if v, ok := pass.TypesInfo.ObjectOf(ident).(*types.Var); ok {
+				h.splitHosts[v] = true
+			}
+		}
+	})
+	return h
+}
+
+// isIPHost reports whether expr is a bare IP address: a net.IP or
+// netip.Addr value, its String(), or a net.SplitHostPort host
+func (h *ipHosts) isIPHost(expr ast.Expr) bool {
+	expr = ast.Unparen(expr)
+	if isIPType(h.pass.TypesInfo.TypeOf(expr)) {
+		return true
+	}
+	switch expr := expr.(type) {
+	case *ast.CallExpr:
+		// ip.String()
+		sel, ok := ast.Unparen(expr.Fun).(*ast.SelectorExpr)
+		return ok && len(expr.Args) == 0 && sel.Sel.Name == "String" && isIPType(h.pass.TypesInfo.TypeOf(sel.X))
+	case *ast.Ident:
+		v, ok := h.pass.TypesInfo.Uses[expr].(*types.Var)
+		return ok && h.splitHosts[v]
+	}
+	return false
+}
+
+// isIPType reports whether t is net.IP or netip.Addr
+func isIPType(t types.Type) bool {
+	return isNamedType(t, "net", "IP") || isNamedType(t, "net/netip", "Addr")
+}
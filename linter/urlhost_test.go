@@ -0,0 +1,11 @@
+package linter
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestURLHost(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData()+"/urlhost", AnalyzerURLHost)
+}
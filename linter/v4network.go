@@ -0,0 +1,85 @@
+package linter
+
+import (
+	"go/ast"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// NetworkAPIs take a network argument but no address checked elsewhere.
+// AnalyzerV4Network checks these along with ListenAPIs and DialAPIs
+var NetworkAPIs = []AddrAPI{
+	{Pkg: "net", Name: "ResolveTCPAddr", Network: 0, Arg: 1},
+	{Pkg: "net", Name: "ResolveUDPAddr", Network: 0, Arg: 1},
+	{Pkg: "net", Name: "ResolveIPAddr", Network: 0, Arg: 1},
+	{Pkg: "net", Name: "ListenIP", Network: 0, Arg: 1, Kind: ArgIPAddr},
+	{Pkg: "net", Name: "DialIP", Network: 0, Arg: 2, Kind: ArgIPAddr},
+	{Pkg: "net", Recv: "Resolver", Name: "LookupIP", Network: 1, Arg: 2},
+	{Pkg: "net", Recv: "Resolver", Name: "LookupNetIP", Network: 1, Arg: 2},
+}
+
+// AnalyzerV4Network reports IPv4-only networks like "tcp4".  Mark
+// intentional uses with a //ip6check:allow-v4only reason comment
+var AnalyzerV4Network = &analysis.Analyzer{
+	Name:     "ipv4network",
+	Doc:      "Reports IPv4-only networks (\"tcp4\", \"udp4\", \"ip4\") passed to net APIs.",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runV4Network,
+}
+
+func runV4Network(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	eval := newStrEvaluator(pass, inspect)
+	allow := newDirectiveIndex(pass, "allow-v4only")
+	tables := [][]AddrAPI{ListenAPIs, DialAPIs, NetworkAPIs}
+	nodeFilter := []ast.Node{
+		(*ast.CallExpr)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		fn := typeutil.StaticCallee(pass.TypesInfo, call)
+		var api *AddrAPI
+		for _, table := range tables {
+			if api = matchAPI(fn, table); api != nil {
+				break
+			}
+		}
+		if api == nil || api.Network == noArg || api.Network >= len(call.Args) {
+			return
+		}
+		network := eval.eval(call.Args[api.Network])
+		if !network.complete || !isV4Network(network.prefix) {
+			return
+		}
+		if d, ok := allow.find(call); ok {
+			if d.reason == "" {
+				pass.Reportf(d.pos, "//ip6check:allow-v4only needs a reason")
+			}
+			return
+		}
+		pass.Reportf(call.Args[api.Network].Pos(), "network %q passed to %s opts out of IPv6; use %q, or mark intentional uses with //ip6check:allow-v4only reason",
+			network.prefix, funcName(fn), dualStackNetwork(network.prefix))
+	})
+	return nil, nil
+}
+
+// isV4Network reports whether network is restricted to IPv4
+func isV4Network(network string) bool {
+	switch network {
+	case "tcp4", "udp4", "ip4":
+		return true
+	}
+	return strings.HasPrefix(network, "ip4:")
+}
+
+// dualStackNetwork returns network without the IPv4 restriction
+func dualStackNetwork(network string) string {
+	if rest, ok := strings.CutPrefix(network, "ip4"); ok {
+		return "ip" + rest
+	}
+	return strings.TrimSuffix(network, "4")
+}
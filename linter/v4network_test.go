@@ -0,0 +1,11 @@
+package linter
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestV4Network(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData()+"/v4network", AnalyzerV4Network)
+}
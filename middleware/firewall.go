@@ -8,32 +8,70 @@ import (
 var ErrFirewall = errors.New("blocked remote addr")
 var ErrIPError = errors.New("error reading remote IP")
 
-// FirewallListener wraps a net.Listener to block non-localhost connections.
+// FirewallListener wraps a net.Listener to block connections from peers
+// its Policy denies.  The default policy, LoopbackPolicy(), admits localhost
+// only; set Policy to admit other networks, e.g. a pod network.
+//
+// By default a blocked connection is closed and Accept returns a *net.OpError
+// wrapping ErrFirewall or ErrIPError.  That error is not Temporary, so
+// http.Serve will return on the first blocked connection.  Set DropBlocked to
+// close blocked connections and keep accepting instead.
 type FirewallListener struct {
 	net.Listener
+	// Policy decides which peers are admitted. nil means LoopbackPolicy()
+	Policy Policy
+	// DropBlocked closes blocked connections and continues the Accept loop
+	// rather than returning an error to the caller
+	DropBlocked bool
+	// OnBlocked (optional) is called with every blocked connection, before it
+	// is closed, with the reason it was blocked (ErrFirewall or ErrIPError)
+	OnBlocked func(conn net.Conn, reason error)
 }
 
 // Accept is the middleware for our firewall. It wraps the underlying Accept call,
-// inspects the connection's IP address, and blocks it if it's not a localhost address.
+// inspects the connection's IP address, and blocks it if the Policy denies it.
 func (fl *FirewallListener) Accept() (net.Conn, error) {
-	conn, err := fl.Listener.Accept()
-	if err != nil {
-		return nil, err
+	for {
+		conn, err := fl.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		reason := fl.check(conn)
+		if reason == nil {
+			return conn, nil
+		}
+		if fl.OnBlocked != nil {
+			fl.OnBlocked(conn, reason)
+		}
+		conn.Close()
+		if !fl.DropBlocked {
+			return conn, &net.OpError{Err: reason}
+		}
 	}
-	tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr)
+}
+
+// check returns the reason conn should be blocked, or nil if it is allowed
+func (fl *FirewallListener) check(conn net.Conn) error {
+	allowed, err := fl.Policy.AllowedAddr(conn.RemoteAddr())
 	// if we can't read the IP, block with IPError
-	if !ok {
-		conn.Close()
-		return conn, &net.OpError{Err: ErrIPError}
+	if err != nil {
+		return err
 	}
-	if !tcpAddr.IP.IsLoopback() {
-		conn.Close()
-		return conn, &net.OpError{Err: ErrFirewall}
+	if !allowed {
+		return ErrFirewall
 	}
-	return conn, nil
+	return nil
 }
 
 // NewFirewallListener creates and returns a new FirewallListener that wraps an existing listener.
 func NewFirewallListener(l net.Listener) *FirewallListener {
-	return &FirewallListener{l}
+	return &FirewallListener{Listener: l}
+}
+
+// NewDropFirewallListener returns a FirewallListener that silently drops
+// blocked connections, so it is safe to pass to http.Serve.
+//
+// onBlocked may be nil
+func NewDropFirewallListener(l net.Listener, onBlocked func(conn net.Conn, reason error)) *FirewallListener {
+	return &FirewallListener{Listener: l, DropBlocked: true, OnBlocked: onBlocked}
 }
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"log"
 	"net"
 	"net/http"
@@ -101,7 +102,7 @@ func TestFirewallListener2(t *testing.T) {
 		mockConn := &mockConn{remoteAddr: remoteAddr}
 
 		// Create a FirewallListener that wraps our mock listener
-		fml := FirewallListener{ml}
+		fml := FirewallListener{Listener: ml}
 
 		go func() {
 			ml.connChan <- mockConn
@@ -114,3 +115,35 @@ func TestFirewallListener2(t *testing.T) {
 
 	})
 }
+
+// TestFirewallListenerDrop verifies that DropBlocked closes blocked
+// connections, reports them to OnBlocked and keeps accepting.
+func TestFirewallListenerDrop(t *testing.T) {
+	ml := newMockListener()
+	blocked := &mockConn{remoteAddr: &net.TCPAddr{IP: net.ParseIP("192.168.1.100"), Port: 54321}}
+	allowed := &mockConn{remoteAddr: &net.TCPAddr{IP: net.ParseIP("::1"), Port: 54322}}
+
+	var reasons []error
+	fml := NewDropFirewallListener(ml, func(conn net.Conn, reason error) {
+		if conn != blocked {
+			t.Errorf("OnBlocked called with unexpected conn from %s", conn.RemoteAddr())
+		}
+		reasons = append(reasons, reason)
+	})
+
+	go func() {
+		ml.connChan <- blocked
+		ml.connChan <- allowed
+	}()
+
+	conn, err := fml.Accept()
+	if err != nil {
+		t.Fatalf("Expected Accept to skip the blocked conn, got error: %v", err)
+	}
+	if conn != allowed {
+		t.Fatalf("Expected the allowed conn, got conn from %s", conn.RemoteAddr())
+	}
+	if len(reasons) != 1 || !errors.Is(reasons[0], ErrFirewall) {
+		t.Fatalf("Expected one ErrFirewall reason, got %v", reasons)
+	}
+}
@@ -1,49 +1,71 @@
 package middleware
 
 import (
+	"context"
 	"net"
 	"net/http"
+	"net/netip"
 )
 
+// connContextKey is the request context key for the accepted net.Conn
+type connContextKey struct{}
+
+// ConnContext stores the accepted net.Conn in the connection's base context.
+// Assign it to http.Server.ConnContext so LocalOnlyMiddleware can read the
+// peer address of the real connection without hijacking it.
+//
+// The context is shared by every request on the connection, so it works
+// with keep-alive and HTTP/2 connections.
+//
+// http.Server has a single ConnContext hook.  To use this alongside
+// multilistener.ConnContext, call one inside the other:
+//
+//	ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+//		return middleware.ConnContext(multilistener.ConnContext(ctx, c), c)
+//	},
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, c)
+}
+
+// ConnFromContext returns the net.Conn stored by ConnContext, or nil
+func ConnFromContext(ctx context.Context) net.Conn {
+	c, _ := ctx.Value(connContextKey{}).(net.Conn)
+	return c
+}
+
 // LocalOnlyMiddleware checks if a request is coming from a local interface
 // by accessing the actual connection's remote address. This version uses a
 // type assertion to get the binary IP address directly, avoiding string parsing.
+//
+// The connection is read from the request context (see ConnContext).  When
+// the server does not use ConnContext, r.RemoteAddr is parsed instead.
 func LocalOnlyMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var conn net.Conn
-		hijacker, ok := w.(http.Hijacker)
-		if ok {
-			// Hijack the connection to get the underlying net.Conn
-			var err error
-			conn, _, err = hijacker.Hijack()
-			if err != nil {
-				http.Error(w, "Forbidden: Failed to hijack connection", http.StatusForbidden)
-				return
-			}
-		}
+	return PolicyMiddleware(nil, next)
+}
 
-		var ip net.IP
-		if conn != nil {
-			// Get the remote address from the connection.
-			remoteAddr := conn.RemoteAddr()
+// PolicyMiddleware is LocalOnlyMiddleware with a custom Policy.  Requests
+// from peers the policy denies receive 403 / Forbidden.  A nil policy is
+// LoopbackPolicy(), as for FirewallListener
+func PolicyMiddleware(policy Policy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ip netip.Addr
+		if conn := ConnFromContext(r.Context()); conn != nil {
 			// Use a type assertion to check if the address is a TCP address.
-			if tcpAddr, ok := remoteAddr.(*net.TCPAddr); ok {
+			if tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
 				// If it is, we can access its IP field directly.
-				ip = tcpAddr.IP
+				ip = tcpAddr.AddrPort().Addr()
 			}
 		}
 
-		if ip == nil {
+		if !ip.IsValid() {
 			// Fallback to r.RemoteAddr if we couldn't get the underlying connection
 			// or if the type assertion failed. This makes the middleware more robust.
-			ipStr, _, err := net.SplitHostPort(r.RemoteAddr)
-			if err == nil {
-				ip = net.ParseIP(ipStr)
+			if addrPort, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+				ip = addrPort.Addr()
 			}
 		}
 
-		// Now we have the net.IP object, so we can use its methods directly.
-		if ip == nil || !ip.IsLoopback() {
+		if !policy.Allowed(ip) {
 			http.Error(w, "Forbidden", http.StatusForbidden)
 			return
 		}
@@ -4,25 +4,15 @@
 package middleware
 
 import (
-	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 )
 
-// A custom ResponseWriter that implements http.Hijacker to test our middleware.
-// This is needed to get to the underlying connection in the middleware code.
-type mockHijackResponseWriter struct {
-	httptest.ResponseRecorder
-	conn net.Conn
-}
-
-func (m *mockHijackResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return m.conn, nil, nil
-}
-
 // TestLocalOnlyMiddleware is the main test function for our middleware.
 func TestLocalOnlyMiddleware(t *testing.T) {
 	// A simple handler to confirm the middleware passed the request through.
@@ -37,8 +27,8 @@ func TestLocalOnlyMiddleware(t *testing.T) {
 		remoteAddr   string
 		expectStatus int
 		expectBody   string
-		// Use a custom conn for the hijacker test case
-		hijackConn net.Conn
+		// conn stored in the request context, as done by ConnContext
+		conn net.Conn
 	}{
 		{
 			name:         "IPv4 Loopback",
@@ -71,16 +61,16 @@ func TestLocalOnlyMiddleware(t *testing.T) {
 			expectBody:   "Forbidden\n",
 		},
 		{
-			name:         "Hijack with IPv4 Loopback",
+			name:         "ConnContext with IPv4 Loopback",
 			remoteAddr:   "192.168.1.1:12345", // RemoteAddr is a fake, but the conn is local.
-			hijackConn:   &mockConn{remoteAddr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 54321}},
+			conn:         &mockConn{remoteAddr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 54321}},
 			expectStatus: http.StatusOK,
 			expectBody:   "Allowed",
 		},
 		{
-			name:         "Hijack with Non-Local IPv4",
+			name:         "ConnContext with Non-Local IPv4",
 			remoteAddr:   "127.0.0.1:12345", // RemoteAddr is a fake, but the conn is not local.
-			hijackConn:   &mockConn{remoteAddr: &net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 54321}},
+			conn:         &mockConn{remoteAddr: &net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 54321}},
 			expectStatus: http.StatusForbidden,
 			expectBody:   "Forbidden\n",
 		},
@@ -91,46 +81,104 @@ func TestLocalOnlyMiddleware(t *testing.T) {
 			// Create a request and a recorder for the test.
 			req := httptest.NewRequest("GET", "http://example.com/", nil)
 			req.RemoteAddr = tc.remoteAddr
-
-			var w http.ResponseWriter
-			if tc.hijackConn != nil {
-				// Use the mockHijackResponseWriter for hijack tests.
-				w = &mockHijackResponseWriter{
-					ResponseRecorder: *httptest.NewRecorder(),
-					conn:             tc.hijackConn,
-				}
-			} else {
-				// Use the standard ResponseRecorder for other tests.
-				w = httptest.NewRecorder()
+			if tc.conn != nil {
+				req = req.WithContext(ConnContext(req.Context(), tc.conn))
 			}
+			w := httptest.NewRecorder()
 
 			// Call the middleware with the test handler.
 			LocalOnlyMiddleware(nextHandler).ServeHTTP(w, req)
 
-			// Get the response and check the status code and body.
-			var resp *http.Response
-			var body string
-			switch v := w.(type) {
-			case *httptest.ResponseRecorder:
-				resp = v.Result()
-				body = v.Body.String()
-			case *mockHijackResponseWriter:
-				resp = v.Result()
-				body = v.ResponseRecorder.Body.String()
-			default:
-				t.Fatalf("unexpected ResponseWriter type: %T", w)
-			}
-
+			resp := w.Result()
 			if resp.StatusCode != tc.expectStatus {
 				t.Errorf("Expected status %d, got %d", tc.expectStatus, resp.StatusCode)
 			}
-			if body != tc.expectBody {
+			if body := w.Body.String(); body != tc.expectBody {
 				t.Errorf("Expected body '%s', got '%s'", tc.expectBody, body)
 			}
 		})
 	}
 }
 
+// TestLocalOnlyMiddlewareServer runs the middleware on a real http.Server
+// using ConnContext, over HTTP/1.1 keep-alive and HTTP/2 connections
+func TestLocalOnlyMiddlewareServer(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ConnFromContext(r.Context()) == nil {
+			t.Errorf("Expected conn in request context")
+		}
+		w.Write([]byte(r.Proto)) //nolint:errcheck
+	})
+	// spoofing RemoteAddr in front of the middleware must not matter, the
+	// real connection is used
+	spoof := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.RemoteAddr = "192.168.1.1:12345"
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	testCases := []struct {
+		name        string
+		http2       bool
+		expectProto string
+	}{
+		{name: "HTTP/1.1 keep-alive", expectProto: "HTTP/1.1"},
+		{name: "HTTP/2", http2: true, expectProto: "HTTP/2.0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewUnstartedServer(spoof(LocalOnlyMiddleware(nextHandler)))
+			ts.Config.ConnContext = ConnContext
+			var conns int
+			ts.Config.ConnState = func(c net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					conns++
+				}
+			}
+			if tc.http2 {
+				ts.EnableHTTP2 = true
+				ts.StartTLS()
+			} else {
+				ts.Start()
+			}
+			defer ts.Close()
+
+			client := ts.Client()
+			// several requests share one connection
+			for i := 0; i < 3; i++ {
+				resp, err := client.Get(ts.URL)
+				if err != nil {
+					t.Fatalf("GET %s: %v", ts.URL, err)
+				}
+				body, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if err != nil {
+					t.Fatalf("Failed to read body: %v", err)
+				}
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+				}
+				if string(body) != tc.expectProto {
+					t.Errorf("Expected proto %s, got %s", tc.expectProto, body)
+				}
+			}
+			ts.Close()
+			if conns != 1 {
+				t.Errorf("Expected requests to reuse 1 connection, got %d", conns)
+			}
+		})
+	}
+}
+
+// TestConnFromContext returns nil when ConnContext was not used
+func TestConnFromContext(t *testing.T) {
+	if c := ConnFromContext(context.Background()); c != nil {
+		t.Errorf("Expected nil conn, got %v", c)
+	}
+}
+
 // ExampleLocalOnlyMiddleware example of wrapping a common handler
 func ExampleLocalOnlyMiddleware() {
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// Rule allows or denies peers within Prefix, or every peer when All is set.
+//
+// A Rule with neither All nor a valid Prefix matches nothing, so an
+// ignored parse error cannot open the firewall
+type Rule struct {
+	Allow  bool
+	All    bool
+	Prefix netip.Prefix
+}
+
+// Match reports whether addr falls within the rule's prefix.  An
+// IPv4-mapped prefix (::ffff:10.0.0.0/104) matches as IPv4 (10.0.0.0/8),
+// as Policy.Allowed unmaps peers
+func (r Rule) Match(addr netip.Addr) bool {
+	if r.All {
+		return true
+	}
+	p := unmapPrefix(r.Prefix)
+	return p.IsValid() && p.Contains(addr)
+}
+
+// unmapPrefix returns the IPv4 prefix of an IPv4-mapped IPv6 prefix, or p
+func unmapPrefix(p netip.Prefix) netip.Prefix {
+	if p.IsValid() && p.Addr().Is4In6() && p.Bits() >= 96 {
+		return netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96).Masked()
+	}
+	return p
+}
+
+// String formats the rule as accepted by ParsePolicy
+func (r Rule) String() string {
+	action := "deny"
+	if r.Allow {
+		action = "allow"
+	}
+	if r.All {
+		return action + " all"
+	}
+	return action + " " + r.Prefix.String()
+}
+
+// AllowPrefix returns a Rule admitting peers within p.  An invalid p
+// admits nobody
+func AllowPrefix(p netip.Prefix) Rule {
+	return Rule{Allow: true, Prefix: p}
+}
+
+// DenyPrefix returns a Rule refusing peers within p
+func DenyPrefix(p netip.Prefix) Rule {
+	return Rule{Prefix: p}
+}
+
+// DenyAll returns a Rule refusing every peer. Use as the last rule of a
+// Policy
+func DenyAll() Rule {
+	return Rule{All: true}
+}
+
+// AllowAll returns a Rule admitting every peer. Use as the last rule of a
+// Policy
+func AllowAll() Rule {
+	return Rule{Allow: true, All: true}
+}
+
+// Policy is an ordered list of allow / deny rules.  The first matching rule
+// decides, and a peer matching no rule is denied.
+//
+// A nil Policy is LoopbackPolicy(), for FirewallListener, PolicyMiddleware
+// and Allowed alike.  An empty non-nil Policy denies everyone.
+//
+// IPv4-mapped IPv6 peers (::ffff:10.0.0.1) are matched as IPv4
+type Policy []Rule
+
+// loopbackPolicy is the default policy, see LoopbackPolicy
+var loopbackPolicy = Policy{
+	AllowPrefix(netip.MustParsePrefix("::1/128")),
+	AllowPrefix(netip.MustParsePrefix("127.0.0.0/8")),
+	DenyAll(),
+}
+
+// LoopbackPolicy returns a Policy admitting ipv6 & ipv4 loopback peers only.
+// It is the default for FirewallListener and LocalOnlyMiddleware
+func LoopbackPolicy() Policy {
+	return slices.Clone(loopbackPolicy)
+}
+
+// ParsePolicy parses a comma separated rule list,
+// e.g. "allow ::1/128, allow 10.0.0.0/8, allow fd00::/8, deny all"
+//
+// a bare address is treated as a single-host prefix.  An empty list
+// returns a nil Policy, i.e. LoopbackPolicy()
+func ParsePolicy(s string) (Policy, error) {
+	var p Policy
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		action, target, ok := strings.Cut(field, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule %q: expected \"allow|deny PREFIX\"", field)
+		}
+		var r Rule
+		switch action {
+		case "allow":
+			r.Allow = true
+		case "deny":
+		default:
+			return nil, fmt.Errorf("invalid rule %q: unknown action %q", field, action)
+		}
+		target = strings.TrimSpace(target)
+		if target == "all" {
+			r.All = true
+		} else {
+			prefix, err := parsePrefix(target)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule %q: %v", field, err)
+			}
+			r.Prefix = prefix
+		}
+		p = append(p, r)
+	}
+	return p, nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		return p.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Allowed reports whether the policy admits addr
+func (p Policy) Allowed(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	if p == nil {
+		p = loopbackPolicy
+	}
+	addr = addr.Unmap().WithZone("")
+	for _, r := range p {
+		if r.Match(addr) {
+			return r.Allow
+		}
+	}
+	return false
+}
+
+// AllowedAddr reports whether the policy admits the peer at a, which must be
+// a *net.TCPAddr or *net.UDPAddr
+func (p Policy) AllowedAddr(a net.Addr) (bool, error) {
+	var ap netip.AddrPort
+	switch a := a.(type) {
+	case *net.TCPAddr:
+		ap = a.AddrPort()
+	case *net.UDPAddr:
+		ap = a.AddrPort()
+	default:
+		return false, ErrIPError
+	}
+	return p.Allowed(ap.Addr()), nil
+}
+
+// String formats the policy as accepted by ParsePolicy
+func (p Policy) String() string {
+	rules := make([]string, len(p))
+	for i, r := range p {
+		rules[i] = r.String()
+	}
+	return strings.Join(rules, ", ")
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestPolicyAllowed(t *testing.T) {
+	policy, err := ParsePolicy("allow ::1/128, allow 10.0.0.0/8, allow fd00::/8, deny all")
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+	testCases := []struct {
+		addr   string
+		expect bool
+	}{
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"::ffff:10.1.2.3", true},
+		{"fd12::1", true},
+		{"fe80::1%eth0", false},
+		{"127.0.0.1", false},
+		{"192.168.1.1", false},
+		{"2001:db8::1", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.addr, func(t *testing.T) {
+			if got := policy.Allowed(netip.MustParseAddr(tc.addr)); got != tc.expect {
+				t.Errorf("Allowed(%s) = %v, expected %v", tc.addr, got, tc.expect)
+			}
+		})
+	}
+	if policy.Allowed(netip.Addr{}) {
+		t.Errorf("Expected invalid addr to be denied")
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	testCases := []struct {
+		in        string
+		expect    string
+		expectErr bool
+	}{
+		{in: "allow ::1/128, allow 10.0.0.0/8, deny all", expect: "allow ::1/128, allow 10.0.0.0/8, deny all"},
+		{in: "allow 10.1.2.3/8,deny 192.168.1.1", expect: "allow 10.0.0.0/8, deny 192.168.1.1/32"},
+		{in: "permit 10.0.0.0/8", expectErr: true},
+		{in: "allow", expectErr: true},
+		{in: "allow 10.0.0.0/33", expectErr: true},
+		{in: "deny 10.0.0.0/8, allow all", expect: "deny 10.0.0.0/8, allow all"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.in, func(t *testing.T) {
+			p, err := ParsePolicy(tc.in)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Expected error, got policy %q", p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePolicy: %v", err)
+			}
+			if p.String() != tc.expect {
+				t.Errorf("Expected %q, got %q", tc.expect, p.String())
+			}
+		})
+	}
+}
+
+// TestRuleInvalidPrefix checks a rule with a zero Prefix matches nothing,
+// while All matches everything
+func TestRuleInvalidPrefix(t *testing.T) {
+	addr := netip.MustParseAddr("192.0.2.1")
+	policy := Policy{AllowPrefix(netip.Prefix{}), DenyAll()}
+	if policy.Allowed(addr) {
+		t.Errorf("Expected AllowPrefix(netip.Prefix{}) to admit nobody")
+	}
+	if !(Policy{AllowAll()}).Allowed(addr) {
+		t.Errorf("Expected AllowAll to admit %s", addr)
+	}
+	if (Policy{DenyAll(), AllowAll()}).Allowed(addr) {
+		t.Errorf("Expected DenyAll to refuse %s", addr)
+	}
+}
+
+// TestPolicyNil checks a nil Policy is LoopbackPolicy() and an empty one
+// denies everyone
+func TestPolicyNil(t *testing.T) {
+	for _, tc := range []struct {
+		ip     string
+		expect bool
+	}{
+		{"::1", true},
+		{"127.0.0.1", true},
+		{"10.0.0.1", false},
+	} {
+		addr := netip.MustParseAddr(tc.ip)
+		if got := Policy(nil).Allowed(addr); got != tc.expect {
+			t.Errorf("nil Policy: expected allowed=%v for %s, got %v", tc.expect, tc.ip, got)
+		}
+		if (Policy{}).Allowed(addr) {
+			t.Errorf("empty Policy: expected %s to be denied", tc.ip)
+		}
+	}
+}
+
+// TestPolicyMappedPrefix checks rules written as IPv4-mapped prefixes match
+// IPv4 and IPv4-mapped peers
+func TestPolicyMappedPrefix(t *testing.T) {
+	policy, err := ParsePolicy("allow ::ffff:10.0.0.0/104, deny all")
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+	for _, ip := range []string{"10.1.2.3", "::ffff:10.1.2.3"} {
+		if !policy.Allowed(netip.MustParseAddr(ip)) {
+			t.Errorf("Expected %s to be allowed", ip)
+		}
+	}
+	if policy.Allowed(netip.MustParseAddr("11.0.0.1")) {
+		t.Errorf("Expected 11.0.0.1 to be denied")
+	}
+}
+
+// TestPolicyShared checks the listener and the middleware agree on one policy
+func TestPolicyShared(t *testing.T) {
+	policy := Policy{
+		AllowPrefix(netip.MustParsePrefix("10.0.0.0/8")),
+		DenyAll(),
+	}
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	testCases := []struct {
+		ip     string
+		expect bool
+	}{
+		{"10.2.3.4", true},
+		{"127.0.0.1", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.ip, func(t *testing.T) {
+			addr := &net.TCPAddr{IP: net.ParseIP(tc.ip), Port: 54321}
+
+			ml := newMockListener()
+			fl := &FirewallListener{Listener: ml, Policy: policy}
+			go func() {
+				ml.connChan <- &mockConn{remoteAddr: addr}
+			}()
+			_, err := fl.Accept()
+			if (err == nil) != tc.expect {
+				t.Errorf("FirewallListener: expected allowed=%v, got err %v", tc.expect, err)
+			}
+
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			req.RemoteAddr = addr.String()
+			w := httptest.NewRecorder()
+			PolicyMiddleware(policy, nextHandler).ServeHTTP(w, req)
+			if (w.Code == http.StatusOK) != tc.expect {
+				t.Errorf("PolicyMiddleware: expected allowed=%v, got status %d", tc.expect, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+)
+
+// Conn is the net.Conn returned by MultiListener.Accept(), tagged with the
+// sub-listener that accepted it.  Use ListenerOf or ConnContext to read the
+// tag from handlers.
+//
+// Closing the Conn removes it from the listener's active set, see Shutdown()
+//
+// Accept() returns a *Conn rather than the sub-listener's conn, so
+// assertions like conn.(*net.TCPConn) fail; use NetConn() to get the
+// underlying conn.  CloseWrite, CloseRead, ReadFrom and WriteTo are
+// forwarded, so half-close and io.Copy's splice/sendfile paths still work
+type Conn struct {
+	net.Conn
+	listener  ListenerInfo
+	dl        *MultiListener
+	closeOnce sync.Once
+}
+
+// ListenerInfo identifies the sub-listener that accepted a Conn
+type ListenerInfo struct {
+	// Index of the listener, in constructor order
+	Index int
+	Addr  net.Addr
+}
+
+// Family returns "ipv6" or "ipv4" for tcp listeners, otherwise the
+// listener's network, e.g. "unix"
+func (li ListenerInfo) Family() string {
+	tcpAddr, ok := li.Addr.(*net.TCPAddr)
+	if !ok {
+		return li.Addr.Network()
+	}
+	if ip := tcpAddr.AddrPort().Addr(); ip.Is4() || ip.Is4In6() {
+		return "ipv4"
+	}
+	return "ipv6"
+}
+
+// accepted is a conn handed from acceptLoop to Accept()
+type accepted struct {
+	conn  net.Conn
+	index int
+}
+
+func (dl *MultiListener) track(a accepted) *Conn {
+	c := &Conn{
+		Conn:     a.conn,
+		listener: ListenerInfo{Index: a.index, Addr: dl.listeners[a.index].Addr()},
+		dl:       dl,
+	}
+	dl.mu.Lock()
+	dl.active[c] = struct{}{}
+	dl.mu.Unlock()
+	return c
+}
+
+// Listener returns the sub-listener that accepted the conn
+func (c *Conn) Listener() ListenerInfo {
+	return c.listener
+}
+
+// NetConn returns the underlying connection, e.g. the *net.TCPConn, like
+// tls.Conn.NetConn
+func (c *Conn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// CloseWrite shuts down the writing side of the underlying connection, like
+// net.TCPConn.CloseWrite
+func (c *Conn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return c.opError("close", errors.ErrUnsupported)
+}
+
+// CloseRead shuts down the reading side of the underlying connection, like
+// net.TCPConn.CloseRead
+func (c *Conn) CloseRead() error {
+	if cr, ok := c.Conn.(interface{ CloseRead() error }); ok {
+		return cr.CloseRead()
+	}
+	return c.opError("close", errors.ErrUnsupported)
+}
+
+// ReadFrom implements io.ReaderFrom, using the underlying connection's
+// ReadFrom when it has one (splice on *net.TCPConn)
+func (c *Conn) ReadFrom(r io.Reader) (int64, error) {
+	if rf, ok := c.Conn.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	// hide ReadFrom so io.Copy does not call back into c
+	return io.Copy(struct{ io.Writer }{c.Conn}, r)
+}
+
+// WriteTo implements io.WriterTo, using the underlying connection's
+// WriteTo when it has one
+func (c *Conn) WriteTo(w io.Writer) (int64, error) {
+	if wt, ok := c.Conn.(io.WriterTo); ok {
+		return wt.WriteTo(w)
+	}
+	// hide WriteTo so io.Copy does not call back into c
+	return io.Copy(w, struct{ io.Reader }{c.Conn})
+}
+
+func (c *Conn) opError(op string, err error) error {
+	return &net.OpError{Op: op, Net: c.LocalAddr().Network(), Source: c.LocalAddr(), Addr: c.RemoteAddr(), Err: err}
+}
+
+// Close closes the underlying connection and removes the conn from the
+// listener's active set, so Shutdown no longer waits for it
+func (c *Conn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() {
+		c.dl.mu.Lock()
+		delete(c.dl.active, c)
+		c.dl.mu.Unlock()
+	})
+	return err
+}
+
+// ListenerOf returns the sub-listener that accepted c.  Wrappers exposing
+// NetConn(), like *tls.Conn, are unwrapped
+func ListenerOf(c net.Conn) (ListenerInfo, bool) {
+	for c != nil {
+		if mc, ok := c.(*Conn); ok {
+			return mc.listener, true
+		}
+		nc, ok := c.(interface{ NetConn() net.Conn })
+		if !ok {
+			break
+		}
+		c = nc.NetConn()
+	}
+	return ListenerInfo{}, false
+}
+
+// listenerContextKey is the request context key for ListenerInfo
+type listenerContextKey struct{}
+
+// ConnContext stores the ListenerInfo of c in the connection's base context.
+// Assign it to http.Server.ConnContext, then read it in handlers with
+// ListenerFromContext
+//
+// http.Server has a single ConnContext hook.  To use this alongside
+// middleware.ConnContext, call one inside the other:
+//
+//	ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+//		return middleware.ConnContext(multilistener.ConnContext(ctx, c), c)
+//	},
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	if li, ok := ListenerOf(c); ok {
+		return context.WithValue(ctx, listenerContextKey{}, li)
+	}
+	return ctx
+}
+
+// ListenerFromContext returns the ListenerInfo stored by ConnContext
+func ListenerFromContext(ctx context.Context) (ListenerInfo, bool) {
+	li, ok := ctx.Value(listenerContextKey{}).(ListenerInfo)
+	return li, ok
+}
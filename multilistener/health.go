@@ -0,0 +1,80 @@
+package multilistener
+
+import (
+	"fmt"
+	"net"
+)
+
+// ListenerStatus is the health of one sub-listener, see Listeners()
+type ListenerStatus struct {
+	// Index of the listener, in constructor order
+	Index int
+	Addr  net.Addr
+	// Err is the permanent error that stopped the listener, nil while healthy
+	Err error
+	// TemporaryErrors counts Accept errors retried with backoff, e.g. EMFILE
+	TemporaryErrors int
+	// LastTemporaryErr is the most recent temporary error
+	LastTemporaryErr error
+}
+
+// Healthy reports whether the listener is still accepting
+func (s ListenerStatus) Healthy() bool {
+	return s.Err == nil
+}
+
+// ListenerError is a permanent Accept error on one sub-listener
+type ListenerError struct {
+	Index int
+	Addr  net.Addr
+	Err   error
+}
+
+func (e *ListenerError) Error() string {
+	return fmt.Sprintf("listener %d (%s) stopped: %v", e.Index, e.Addr, e.Err)
+}
+
+func (e *ListenerError) Unwrap() error {
+	return e.Err
+}
+
+// Listeners returns the health of every sub-listener
+func (dl *MultiListener) Listeners() []ListenerStatus {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	return append([]ListenerStatus(nil), dl.status...)
+}
+
+// SetErrorHandler calls fn with each permanent sub-listener error, e.g. to
+// log it.  Accept() keeps serving the other listeners either way.  Set it
+// right after construction, errors reported before are not passed to fn
+func (dl *MultiListener) SetErrorHandler(fn func(err *ListenerError)) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	dl.onError = fn
+}
+
+func (dl *MultiListener) recordTemporary(index int, err error) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	dl.status[index].TemporaryErrors++
+	dl.status[index].LastTemporaryErr = err
+}
+
+// reportError marks the listener as failed and hands the error to the
+// error handler.  When it was the last live listener, Accept() returns it
+func (dl *MultiListener) reportError(index int, err error) {
+	lerr := &ListenerError{Index: index, Addr: dl.listeners[index].Addr(), Err: err}
+	dl.mu.Lock()
+	dl.status[index].Err = err
+	onError := dl.onError
+	dl.live--
+	if dl.live == 0 {
+		dl.doneErr = lerr
+		close(dl.doneCh)
+	}
+	dl.mu.Unlock()
+	if onError != nil {
+		onError(lerr)
+	}
+}
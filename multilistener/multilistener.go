@@ -8,15 +8,23 @@ but cannot listen to all local interfaces by default.
 use multilistener.ListenLocalLoopback to return a single Listener for all ipv4 &
 ipv6 loopback interfaces
 
+use multilistener.NewLocalLoopbackUDP for the same over udp, with a single
+net.PacketConn
+
 ©️ 2025 Anthony Metzidis
 */
 package multilistener
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 // MultiListener implements net.Listener interface
@@ -24,9 +32,24 @@ import (
 // multiplexes multiple net.Listeners concurrently looping over Accept()
 type MultiListener struct {
 	listeners []net.Listener
-	acceptCh  chan net.Conn
+	acceptCh  chan accepted
 	closeCh   chan struct{}
+	closeOnce sync.Once
 	wg        sync.WaitGroup
+	// accept deadline, see SetDeadline()
+	adeadline deadline
+	// closed once every sub-listener has failed, Accept() then returns
+	// doneErr
+	doneCh  chan struct{}
+	doneErr error
+	// active conns returned by Accept(), drained by Shutdown()
+	mu     sync.Mutex
+	active map[*Conn]struct{}
+	// health of each listener, see Listeners()
+	status  []ListenerStatus
+	onError func(*ListenerError)
+	// sub-listeners still accepting
+	live int
 }
 
 type Addresses = []string
@@ -56,11 +79,80 @@ func (dl *MultiListener) String() string {
 	return r.String()
 }
 
+// loopbackHosts in order of preference
+var loopbackHosts = []string{"::1", "127.0.0.1"}
+
+// maxPortAttempts bounds how many ephemeral ports NewLocalLoopback("0") tries
+// before giving up on finding one that is free on every family
+const maxPortAttempts = 10
+
 // NewLocalLoopback returns Multilistener on ipv6 & ipv4 loopback addresses
 //
 // ipv6 is the preferred address when Addr() is called
+//
+// with port "0" or "" the kernel picks a port on ipv6, and the same port is
+// used for ipv4, so one port number reaches the service on both loopbacks
 func NewLocalLoopback(port string) (*MultiListener, error) {
-	return NewMultiListener(Addresses{"[::1]:" + port, "127.0.0.1:" + port})
+	if isEphemeralPort(port) {
+		listeners, err := listenSamePort(loopbackHosts)
+		if err != nil {
+			return nil, err
+		}
+		return NewMultiListenerRaw(listeners)
+	}
+	addrs := make(Addresses, 0, len(loopbackHosts))
+	for _, host := range loopbackHosts {
+		addrs = append(addrs, net.JoinHostPort(host, port))
+	}
+	return NewMultiListener(addrs)
+}
+
+// isEphemeralPort reports whether port asks the kernel to pick one, as
+// net.Listen does for both "0" and ""
+func isEphemeralPort(port string) bool {
+	return port == "0" || port == ""
+}
+
+// listenSamePort listens on an ephemeral port on hosts[0], then on the same
+// port on the remaining hosts.  If the port is taken on another host, all
+// listeners are closed and a new port is tried, up to maxPortAttempts
+func listenSamePort(hosts []string) ([]net.Listener, error) {
+	var lastErr error
+	for attempt := 0; attempt < maxPortAttempts; attempt++ {
+		first, err := net.Listen("tcp", net.JoinHostPort(hosts[0], "0"))
+		if err != nil {
+			return nil, fmt.Errorf("listen error: %v", err)
+		}
+		port := strconv.Itoa(first.Addr().(*net.TCPAddr).Port)
+		listeners, err := listenAll(hosts[1:], port)
+		if err == nil {
+			return append([]net.Listener{first}, listeners...), nil
+		}
+		first.Close()
+		lastErr = err
+	}
+	return nil, fmt.Errorf("no common port after %d attempts: %v", maxPortAttempts, lastErr)
+}
+
+// listenAll listens on port for every host, closing any listener already
+// opened if one fails
+func listenAll(hosts []string, port string) ([]net.Listener, error) {
+	var listeners = make([]net.Listener, 0, len(hosts))
+	for _, host := range hosts {
+		ln, err := net.Listen("tcp", net.JoinHostPort(host, port))
+		if err != nil {
+			closeAll(listeners)
+			return nil, fmt.Errorf("listen error: %v", err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+func closeAll(listeners []net.Listener) {
+	for _, ln := range listeners {
+		ln.Close()
+	}
 }
 
 // NewMultiListenerRaw returns a MultiListener wrapper of multiple listeners
@@ -69,12 +161,24 @@ func NewLocalLoopback(port string) (*MultiListener, error) {
 func NewMultiListenerRaw(listeners []net.Listener) (*MultiListener, error) {
 	dl := &MultiListener{
 		listeners: listeners,
-		acceptCh:  make(chan net.Conn),
+		acceptCh:  make(chan accepted),
 		closeCh:   make(chan struct{}),
+		adeadline: makeDeadline(),
+		active:    make(map[*Conn]struct{}),
+		doneCh:    make(chan struct{}),
+		status:    make([]ListenerStatus, len(listeners)),
+		live:      len(listeners),
 	}
-	for _, l := range dl.listeners {
+	for i, l := range listeners {
+		dl.status[i] = ListenerStatus{Index: i, Addr: l.Addr()}
+	}
+	if len(listeners) == 0 {
+		dl.doneErr = dl.opError("accept", net.ErrClosed)
+		close(dl.doneCh)
+	}
+	for i := range dl.listeners {
 		dl.wg.Add(1)
-		go dl.acceptLoop(l)
+		go dl.acceptLoop(i)
 	}
 	return dl, nil
 }
@@ -88,20 +192,12 @@ func NewMultiListener(addrs Addresses) (*MultiListener, error) {
 	for _, addr := range addrs {
 		ln, err := net.Listen("tcp", addr)
 		if err != nil {
+			closeAll(listeners)
 			return nil, fmt.Errorf("listen error: %v", err)
 		}
 		listeners = append(listeners, ln)
 	}
-	dl := &MultiListener{
-		listeners: listeners,
-		acceptCh:  make(chan net.Conn),
-		closeCh:   make(chan struct{}),
-	}
-	for _, l := range dl.listeners {
-		dl.wg.Add(1)
-		go dl.acceptLoop(l)
-	}
-	return dl, nil
+	return NewMultiListenerRaw(listeners)
 }
 
 // AllAddr returns all the addresses, comma-separated
@@ -111,8 +207,17 @@ func (dl *MultiListener) AllAddr() net.Addr {
 	return dl
 }
 
-func (dl *MultiListener) acceptLoop(l net.Listener) {
+// acceptBackoffMin and acceptBackoffMax bound the sleep after a temporary
+// Accept error, same as net/http
+const (
+	acceptBackoffMin = 5 * time.Millisecond
+	acceptBackoffMax = time.Second
+)
+
+func (dl *MultiListener) acceptLoop(index int) {
 	defer dl.wg.Done()
+	l := dl.listeners[index]
+	var backoff time.Duration
 	for {
 		conn, err := l.Accept()
 		if err != nil {
@@ -120,44 +225,146 @@ func (dl *MultiListener) acceptLoop(l net.Listener) {
 			case <-dl.closeCh:
 				return
 			default:
-				continue
 			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() { //nolint:staticcheck // EMFILE etc. are still reported as Temporary
+				backoff = min(max(backoff*2, acceptBackoffMin), acceptBackoffMax)
+				dl.recordTemporary(index, err)
+				select {
+				case <-time.After(backoff):
+					continue
+				case <-dl.closeCh:
+					return
+				}
+			}
+			// permanent error: this listener is done, report it
+			dl.reportError(index, err)
+			return
+		}
+		backoff = 0
+		// hand off to Accept(), unless Close() is called first. In that case
+		// nobody will pick up conn, so close it
+		select {
+		case dl.acceptCh <- accepted{conn: conn, index: index}:
+		case <-dl.closeCh:
+			conn.Close()
+			return
 		}
-		dl.acceptCh <- conn
 	}
 }
 
+// Accept waits for the next connection from any of the listeners
+//
+// the conn is a *Conn, tagged with the listener that accepted it.  Use
+// Conn.NetConn() to reach the sub-listener's conn, e.g. a *net.TCPConn
+//
+// a permanent error on one listener is not returned: the other listeners
+// keep accepting, and the error is recorded in Listeners() and passed to
+// the error handler (see SetErrorHandler).  Once every listener has failed,
+// Accept returns the last *ListenerError, so http.Serve exits instead of
+// blocking forever.
+//
+// after Close() the error satisfies errors.Is(err, net.ErrClosed)
 func (dl *MultiListener) Accept() (net.Conn, error) {
+	// prefer reporting close over a conn racing in from acceptLoop
+	select {
+	case <-dl.closeCh:
+		return nil, dl.opError("accept", net.ErrClosed)
+	default:
+	}
 	select {
-	case conn := <-dl.acceptCh:
-		return conn, nil
+	case a := <-dl.acceptCh:
+		return dl.track(a), nil
+	case <-dl.doneCh:
+		return nil, dl.doneErr
+	case <-dl.adeadline.wait():
+		return nil, dl.opError("accept", os.ErrDeadlineExceeded)
 	case <-dl.closeCh:
-		return nil, fmt.Errorf("listener closed")
+		return nil, dl.opError("accept", net.ErrClosed)
+	}
+}
+
+// SetDeadline sets the deadline for Accept() across all listeners, like
+// net.TCPListener.SetDeadline.  Once t passes, Accept returns a net.Error
+// with Timeout() true.  A zero t disables the deadline.
+//
+// connections arriving after the deadline wait for the next Accept()
+func (dl *MultiListener) SetDeadline(t time.Time) error {
+	if isClosedChan(dl.closeCh) {
+		return dl.opError("set", net.ErrClosed)
 	}
+	dl.adeadline.set(t)
+	return nil
 }
 
-// Close closes all internal channels
+// Close stops all listeners.  Connections already returned by Accept() are
+// left open, see Shutdown()
+//
+// Close is idempotent: later calls return an error satisfying
+// errors.Is(err, net.ErrClosed)
 //
 // do not defer Close() if passing to http.Server
-func (dl *MultiListener) Close() (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("MultiListener.Close() panicked on second call: %v", r)
-		}
-	}()
+func (dl *MultiListener) Close() error {
+	err := dl.opError("close", net.ErrClosed)
+	dl.closeOnce.Do(func() {
+		err = dl.close()
+	})
+	return err
+}
+
+func (dl *MultiListener) close() error {
 	close(dl.closeCh)
-	// todo clean up ugly
 	var firstErr error
 	for _, l := range dl.listeners {
 		if err := l.Close(); err != nil && firstErr == nil {
 			firstErr = err
 		}
 	}
+	// acceptLoops exit once their listener is closed or closeCh wakes the
+	// handoff to acceptCh
 	dl.wg.Wait()
-	close(dl.acceptCh)
 	return firstErr
 }
 
+// shutdownPollIntervalMax caps the wait between polls for active conns
+const shutdownPollIntervalMax = 500 * time.Millisecond
+
+// Shutdown closes the listeners, then waits until every connection returned
+// by Accept() has been closed, or ctx expires.  Like http.Server.Shutdown it
+// returns ctx.Err() when the context expires first, and does not close the
+// remaining connections.
+func (dl *MultiListener) Shutdown(ctx context.Context) error {
+	if err := dl.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	pollInterval := time.Millisecond
+	timer := time.NewTimer(pollInterval)
+	defer timer.Stop()
+	for {
+		if dl.ActiveConns() == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			pollInterval = min(pollInterval*2, shutdownPollIntervalMax)
+			timer.Reset(pollInterval)
+		}
+	}
+}
+
+// ActiveConns returns the number of connections returned by Accept() that
+// have not been closed yet
+func (dl *MultiListener) ActiveConns() int {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	return len(dl.active)
+}
+
+func (dl *MultiListener) opError(op string, err error) error {
+	return &net.OpError{Op: op, Net: dl.Network(), Addr: dl, Err: err}
+}
+
 // Addr returns the preferred (first) interface Addr
 func (dl *MultiListener) Addr() net.Addr {
 	return dl.listeners[0].Addr()
@@ -1,12 +1,16 @@
 package multilistener
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"testing"
 	"time"
+
+	"github.com/tonymet/dualstack/middleware"
 )
 
 // TestListenOnlyIPV6 sanity check that IPv6 Socket cannot receive ipv4 requests
@@ -140,8 +144,8 @@ func TestMultiListenerDoubleClose(t *testing.T) {
 	}()
 
 	err = ml.Close()
-	if err == nil {
-		t.Errorf("Second close succeeded unexpectedly, should return an error or be a no-op")
+	if !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Second close should return net.ErrClosed, got %v", err)
 	}
 	t.Logf("Second close returned: %v (expected error or no-op)", err)
 }
@@ -164,9 +168,496 @@ func TestMultiListenerCloseBeforeServe(t *testing.T) {
 		t.Errorf("Handler should not be called on a closed listener")
 	}))
 
-	if serveErr == nil {
-		t.Errorf("http.Serve on a closed listener did not return an error")
+	if !errors.Is(serveErr, net.ErrClosed) {
+		t.Errorf("http.Serve on a closed listener should return net.ErrClosed, got %v", serveErr)
 	} else {
 		t.Logf("http.Serve on a closed listener returned expected error: %v", serveErr)
 	}
 }
+
+// TestMultiListenerCloseWithPendingConn regression test: Close() must not
+// deadlock when acceptLoop holds a conn that nobody Accept()s
+func TestMultiListenerCloseWithPendingConn(t *testing.T) {
+	ml, err := NewLocalLoopback("0")
+	if err != nil {
+		t.Fatalf("Failed to create MultiListener: %v", err)
+	}
+	// connect to every listener, but never call Accept()
+	var clients []net.Conn
+	for _, l := range ml.listeners {
+		c, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatalf("Failed to dial %s: %v", l.Addr(), err)
+		}
+		defer c.Close()
+		clients = append(clients, c)
+	}
+	// give acceptLoop time to block on the handoff
+	time.Sleep(100 * time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		done <- ml.Close()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Close returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Close deadlocked with pending connections")
+	}
+
+	// the pending connections were closed by the listener
+	for _, c := range clients {
+		if err := c.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+			t.Fatalf("SetReadDeadline: %v", err)
+		}
+		if _, err := c.Read(make([]byte, 1)); err != io.EOF {
+			t.Errorf("Expected EOF on pending conn %s, got %v", c.LocalAddr(), err)
+		}
+	}
+}
+
+func TestMultiListenerShutdown(t *testing.T) {
+	ml, err := NewLocalLoopback("0")
+	if err != nil {
+		t.Fatalf("Failed to create MultiListener: %v", err)
+	}
+	client, err := net.Dial("tcp", ml.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer client.Close()
+	server, err := ml.Accept()
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	if n := ml.ActiveConns(); n != 1 {
+		t.Fatalf("Expected 1 active conn, got %d", n)
+	}
+
+	// the active conn keeps Shutdown waiting until ctx expires
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := ml.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected DeadlineExceeded with an active conn, got %v", err)
+	}
+	if _, err := ml.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Expected Accept after Shutdown to return net.ErrClosed, got %v", err)
+	}
+
+	// closing the conn drains the listener
+	done := make(chan error)
+	go func() {
+		done <- ml.Shutdown(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := server.Close(); err != nil {
+		t.Fatalf("Close conn: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Shutdown returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Shutdown did not return after the last conn closed")
+	}
+	if n := ml.ActiveConns(); n != 0 {
+		t.Errorf("Expected 0 active conns, got %d", n)
+	}
+}
+
+// TestLocalLoopbackSamePort every family listens on the same ephemeral port,
+// for port "0" and ""
+func TestLocalLoopbackSamePort(t *testing.T) {
+	for _, port := range []string{"0", ""} {
+		t.Run(fmt.Sprintf("%q", port), func(t *testing.T) {
+			ml, err := NewLocalLoopback(port)
+			if err != nil {
+				t.Fatalf("Failed to create MultiListener: %v", err)
+			}
+			defer ml.Close()
+			want := ml.Addr().(*net.TCPAddr).Port
+			if want == 0 {
+				t.Fatalf("Expected an ephemeral port, got 0")
+			}
+			for _, l := range ml.listeners {
+				if p := l.Addr().(*net.TCPAddr).Port; p != want {
+					t.Errorf("Expected port %d on %s, got %d", want, l.Addr(), p)
+				}
+			}
+			t.Logf("MultiListener serving on: %s", ml.AllAddr())
+		})
+	}
+}
+
+// TestListenSamePortTaken fails within maxPortAttempts when a host can never
+// listen
+func TestListenSamePortTaken(t *testing.T) {
+	_, err := listenSamePort([]string{"::1", "192.0.2.1"})
+	if err == nil {
+		t.Fatalf("Expected error listening on a non-local address")
+	}
+	t.Logf("listenSamePort returned expected error: %v", err)
+}
+
+// tempError is a Temporary net.Error, like EMFILE
+type tempError struct{}
+
+func (tempError) Error() string   { return "too many open files" }
+func (tempError) Timeout() bool   { return false }
+func (tempError) Temporary() bool { return true }
+
+// errListener returns errs from Accept, then blocks until closed
+//
+// if start is set, Accept waits for it to close first
+type errListener struct {
+	net.Listener
+	start   chan struct{}
+	errs    []error
+	calls   int
+	closeCh chan struct{}
+}
+
+func newErrListener(errs ...error) *errListener {
+	return &errListener{errs: errs, closeCh: make(chan struct{})}
+}
+
+func (l *errListener) Accept() (net.Conn, error) {
+	if l.start != nil {
+		<-l.start
+	}
+	if l.calls < len(l.errs) {
+		l.calls++
+		return nil, l.errs[l.calls-1]
+	}
+	<-l.closeCh
+	return nil, net.ErrClosed
+}
+
+func (l *errListener) Addr() net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP("::1"), Port: 8080}
+}
+
+func (l *errListener) Close() error {
+	close(l.closeCh)
+	return nil
+}
+
+func TestAcceptLoopBackoff(t *testing.T) {
+	el := newErrListener(tempError{}, tempError{}, tempError{})
+	ml, err := NewMultiListenerRaw([]net.Listener{el})
+	if err != nil {
+		t.Fatalf("NewMultiListenerRaw: %v", err)
+	}
+	defer ml.Close()
+	// 5ms + 10ms + 20ms of backoff
+	time.Sleep(100 * time.Millisecond)
+	status := ml.Listeners()[0]
+	if status.TemporaryErrors != 3 {
+		t.Errorf("Expected 3 temporary errors, got %d", status.TemporaryErrors)
+	}
+	if !status.Healthy() {
+		t.Errorf("Expected listener to stay healthy after temporary errors: %v", status.Err)
+	}
+}
+
+func TestAcceptLoopPermanentError(t *testing.T) {
+	permanent := errors.New("listener broken")
+	good, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ml, err := NewMultiListenerRaw([]net.Listener{good, newErrListener(permanent)})
+	if err != nil {
+		t.Fatalf("NewMultiListenerRaw: %v", err)
+	}
+	defer ml.Close()
+
+	// the healthy listener keeps accepting, the error is not returned
+	c, err := net.Dial("tcp", good.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer c.Close()
+	conn, err := ml.Accept()
+	if err != nil {
+		t.Fatalf("Accept on healthy listener: %v", err)
+	}
+	conn.Close()
+
+	status := ml.Listeners()
+	if !status[0].Healthy() || !errors.Is(status[1].Err, permanent) {
+		t.Errorf("Expected only listener 1 to be unhealthy: %+v", status)
+	}
+}
+
+func TestAcceptAllListenersFailed(t *testing.T) {
+	permanent := errors.New("listener broken")
+	ml, err := NewMultiListenerRaw([]net.Listener{newErrListener(permanent), newErrListener(permanent)})
+	if err != nil {
+		t.Fatalf("NewMultiListenerRaw: %v", err)
+	}
+	defer ml.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := ml.Accept()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		var lerr *ListenerError
+		if !errors.As(err, &lerr) || !errors.Is(err, permanent) {
+			t.Fatalf("Expected ListenerError, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Accept blocked after every listener failed")
+	}
+}
+
+func TestAcceptNoListeners(t *testing.T) {
+	ml, err := NewMultiListenerRaw(nil)
+	if err != nil {
+		t.Fatalf("NewMultiListenerRaw: %v", err)
+	}
+	defer ml.Close()
+	if _, err := ml.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("Expected net.ErrClosed, got %v", err)
+	}
+}
+
+func TestAcceptLoopErrorHandler(t *testing.T) {
+	permanent := errors.New("listener broken")
+	el := newErrListener(permanent)
+	el.start = make(chan struct{})
+	ml, err := NewMultiListenerRaw([]net.Listener{el})
+	if err != nil {
+		t.Fatalf("NewMultiListenerRaw: %v", err)
+	}
+	defer ml.Close()
+	handled := make(chan *ListenerError, 1)
+	ml.SetErrorHandler(func(err *ListenerError) {
+		handled <- err
+	})
+	close(el.start)
+	select {
+	case err := <-handled:
+		if !errors.Is(err, permanent) {
+			t.Errorf("Expected permanent error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Error handler was not called")
+	}
+}
+
+// TestConnContext handlers see which listener accepted the request
+func TestConnContext(t *testing.T) {
+	ml, err := NewLocalLoopback("0")
+	if err != nil {
+		t.Fatalf("Failed to create MultiListener: %v", err)
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			li, ok := ListenerFromContext(r.Context())
+			if !ok {
+				t.Errorf("Expected ListenerInfo in request context")
+				return
+			}
+			fmt.Fprintf(w, "%d %s %s", li.Index, li.Family(), li.Addr)
+		}),
+		ConnContext: ConnContext,
+	}
+	go srv.Serve(ml) //nolint:errcheck
+	defer srv.Close()
+
+	families := []string{"ipv6", "ipv4"}
+	for i, l := range ml.listeners {
+		addr := l.Addr().String()
+		resp, err := http.Get("http://" + addr)
+		if err != nil {
+			t.Fatalf("Failed to connect to %s: %v", addr, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Failed to read response body from %s: %v", addr, err)
+		}
+		expect := fmt.Sprintf("%d %s %s", i, families[i], addr)
+		if string(body) != expect {
+			t.Errorf("Expected %q, got %q", expect, body)
+		}
+	}
+}
+
+// TestConnContextChained multilistener.ConnContext and middleware.ConnContext
+// share the server's single ConnContext hook
+func TestConnContextChained(t *testing.T) {
+	ml, err := NewLocalLoopback("0")
+	if err != nil {
+		t.Fatalf("Failed to create MultiListener: %v", err)
+	}
+	srv := &http.Server{
+		Handler: middleware.LocalOnlyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			li, ok := ListenerFromContext(r.Context())
+			if !ok {
+				t.Errorf("Expected ListenerInfo in request context")
+			}
+			if middleware.ConnFromContext(r.Context()) == nil {
+				t.Errorf("Expected conn in request context")
+			}
+			fmt.Fprint(w, li.Family())
+		})),
+		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+			return middleware.ConnContext(ConnContext(ctx, c), c)
+		},
+	}
+	go srv.Serve(ml) //nolint:errcheck
+	defer srv.Close()
+
+	families := []string{"ipv6", "ipv4"}
+	for i, l := range ml.listeners {
+		addr := l.Addr().String()
+		resp, err := http.Get("http://" + addr)
+		if err != nil {
+			t.Fatalf("Failed to connect to %s: %v", addr, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Failed to read response body from %s: %v", addr, err)
+		}
+		if resp.StatusCode != http.StatusOK || string(body) != families[i] {
+			t.Errorf("Expected 200 %q from %s, got %d %q", families[i], addr, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestListenerOfUnix(t *testing.T) {
+	ul, err := net.Listen("unix", t.TempDir()+"/ml.sock")
+	if err != nil {
+		t.Fatalf("Failed to listen on unix socket: %v", err)
+	}
+	ml, err := NewMultiListenerRaw([]net.Listener{ul})
+	if err != nil {
+		t.Fatalf("NewMultiListenerRaw: %v", err)
+	}
+	defer ml.Close()
+	c, err := net.Dial("unix", ul.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer c.Close()
+	conn, err := ml.Accept()
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	defer conn.Close()
+	li, ok := ListenerOf(conn)
+	if !ok || li.Index != 0 || li.Family() != "unix" {
+		t.Errorf("Expected unix listener 0, got %+v %v", li, ok)
+	}
+	if _, ok := ListenerOf(c); ok {
+		t.Errorf("Expected no ListenerInfo on a plain conn")
+	}
+}
+
+// TestConnForwarding *Conn keeps the half-close and io.Copy methods of
+// the underlying *net.TCPConn
+func TestConnForwarding(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ml, err := NewMultiListenerRaw([]net.Listener{l})
+	if err != nil {
+		t.Fatalf("NewMultiListenerRaw: %v", err)
+	}
+	defer ml.Close()
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer c.Close()
+	conn, err := ml.Accept()
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	defer conn.Close()
+
+	if _, ok := conn.(interface{ NetConn() net.Conn }).NetConn().(*net.TCPConn); !ok {
+		t.Errorf("Expected NetConn() to return *net.TCPConn")
+	}
+	if _, ok := conn.(io.ReaderFrom); !ok {
+		t.Errorf("Expected *Conn to implement io.ReaderFrom")
+	}
+	if _, ok := conn.(io.WriterTo); !ok {
+		t.Errorf("Expected *Conn to implement io.WriterTo")
+	}
+	// the client reads EOF after CloseWrite, while the server can still read
+	cw, ok := conn.(interface{ CloseWrite() error })
+	if !ok {
+		t.Fatalf("Expected *Conn to implement CloseWrite")
+	}
+	if err := cw.CloseWrite(); err != nil {
+		t.Fatalf("CloseWrite: %v", err)
+	}
+	c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := c.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected EOF after CloseWrite, got %v", err)
+	}
+	if _, err := c.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("Expected ping after CloseWrite, got %q %v", buf, err)
+	}
+}
+
+func TestMultiListenerSetDeadline(t *testing.T) {
+	ml, err := NewLocalLoopback("0")
+	if err != nil {
+		t.Fatalf("Failed to create MultiListener: %v", err)
+	}
+	defer ml.Close()
+
+	if err := ml.SetDeadline(time.Now().Add(20 * time.Millisecond)); err != nil {
+		t.Fatalf("SetDeadline: %v", err)
+	}
+	start := time.Now()
+	_, err = ml.Accept()
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatalf("Expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Accept returned %s after the deadline", elapsed)
+	}
+
+	// clearing the deadline accepts again, on every family
+	if err := ml.SetDeadline(time.Time{}); err != nil {
+		t.Fatalf("SetDeadline: %v", err)
+	}
+	for _, l := range ml.listeners {
+		c, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatalf("Failed to dial %s: %v", l.Addr(), err)
+		}
+		defer c.Close()
+		conn, err := ml.Accept()
+		if err != nil {
+			t.Fatalf("Accept after clearing deadline: %v", err)
+		}
+		conn.Close()
+	}
+
+	if err := ml.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := ml.SetDeadline(time.Now()); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("SetDeadline after close should return net.ErrClosed, got %v", err)
+	}
+}
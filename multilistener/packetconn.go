@@ -0,0 +1,328 @@
+package multilistener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDatagramSize is the read buffer per underlying PacketConn
+const maxDatagramSize = 64 * 1024
+
+// ErrNoConnForAddr is returned by WriteTo when no underlying PacketConn
+// matches the destination address family
+var ErrNoConnForAddr = errors.New("no packet conn for destination address family")
+
+// MultiPacketConn implements net.PacketConn interface
+//
+// multiplexes ReadFrom() over multiple net.PacketConns, and sends WriteTo()
+// through the conn matching the destination address family
+type MultiPacketConn struct {
+	conns     []net.PacketConn
+	readCh    chan packet
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+	rdeadline deadline
+	// closed once every readLoop has stopped on an error, ReadFrom then
+	// returns doneErr
+	mu      sync.Mutex
+	live    int
+	doneCh  chan struct{}
+	doneErr error
+}
+
+// packet read by one of the readLoops
+type packet struct {
+	data []byte
+	addr net.Addr
+	err  error
+}
+
+// NewLocalLoopbackUDP returns MultiPacketConn on ipv6 & ipv4 loopback addresses
+//
+// ipv6 is the preferred address when LocalAddr() is called.  As with
+// NewLocalLoopback, port "0" or "" picks one port shared by both families
+func NewLocalLoopbackUDP(port string) (*MultiPacketConn, error) {
+	if isEphemeralPort(port) {
+		conns, err := listenPacketSamePort(loopbackHosts)
+		if err != nil {
+			return nil, err
+		}
+		return NewMultiPacketConnRaw(conns)
+	}
+	addrs := make(Addresses, 0, len(loopbackHosts))
+	for _, host := range loopbackHosts {
+		addrs = append(addrs, net.JoinHostPort(host, port))
+	}
+	return NewMultiPacketConn(addrs)
+}
+
+// NewMultiPacketConn returns MultiPacketConn over udp addresses
+//
+// e.g. "[::1]:8053" for ipv6 and "127.0.0.1:8053" for ipv4
+func NewMultiPacketConn(addrs Addresses) (*MultiPacketConn, error) {
+	var conns = make([]net.PacketConn, 0, len(addrs))
+	for _, addr := range addrs {
+		pc, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			closePacketConns(conns)
+			return nil, fmt.Errorf("listen error: %v", err)
+		}
+		conns = append(conns, pc)
+	}
+	return NewMultiPacketConnRaw(conns)
+}
+
+// NewMultiPacketConnRaw returns a MultiPacketConn wrapper of multiple PacketConns
+func NewMultiPacketConnRaw(conns []net.PacketConn) (*MultiPacketConn, error) {
+	mc := &MultiPacketConn{
+		conns:     conns,
+		readCh:    make(chan packet),
+		closeCh:   make(chan struct{}),
+		rdeadline: makeDeadline(),
+		live:      len(conns),
+		doneCh:    make(chan struct{}),
+	}
+	if len(conns) == 0 {
+		mc.doneErr = mc.opError("read", net.ErrClosed)
+		close(mc.doneCh)
+	}
+	for _, pc := range mc.conns {
+		mc.wg.Add(1)
+		go mc.readLoop(pc)
+	}
+	return mc, nil
+}
+
+// listenPacketSamePort is listenSamePort for udp
+func listenPacketSamePort(hosts []string) ([]net.PacketConn, error) {
+	var lastErr error
+	for attempt := 0; attempt < maxPortAttempts; attempt++ {
+		first, err := net.ListenPacket("udp", net.JoinHostPort(hosts[0], "0"))
+		if err != nil {
+			return nil, fmt.Errorf("listen error: %v", err)
+		}
+		port := strconv.Itoa(first.LocalAddr().(*net.UDPAddr).Port)
+		conns := []net.PacketConn{first}
+		for _, host := range hosts[1:] {
+			var pc net.PacketConn
+			pc, err = net.ListenPacket("udp", net.JoinHostPort(host, port))
+			if err != nil {
+				break
+			}
+			conns = append(conns, pc)
+		}
+		if err == nil {
+			return conns, nil
+		}
+		closePacketConns(conns)
+		lastErr = err
+	}
+	return nil, fmt.Errorf("no common port after %d attempts: %v", maxPortAttempts, lastErr)
+}
+
+func closePacketConns(conns []net.PacketConn) {
+	for _, pc := range conns {
+		pc.Close()
+	}
+}
+
+func (mc *MultiPacketConn) readLoop(pc net.PacketConn) {
+	defer mc.wg.Done()
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		select {
+		case <-mc.closeCh:
+			return
+		default:
+		}
+		p := packet{addr: addr, err: err}
+		if n > 0 {
+			p.data = append([]byte(nil), buf[:n]...)
+		}
+		select {
+		case mc.readCh <- p:
+		case <-mc.closeCh:
+			return
+		}
+		if ne, ok := err.(net.Error); err != nil && !(ok && ne.Temporary()) { //nolint:staticcheck // same check as acceptLoop
+			mc.readerDone(err)
+			return
+		}
+	}
+}
+
+// readerDone counts a readLoop stopped by err.  When it was the last one,
+// ReadFrom returns err from then on instead of blocking
+func (mc *MultiPacketConn) readerDone(err error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.live--
+	if mc.live == 0 {
+		mc.doneErr = err
+		close(mc.doneCh)
+	}
+}
+
+// ReadFrom reads the next datagram from any of the conns
+//
+// datagrams larger than p are truncated, like a single UDP socket.  A
+// permanent error on one conn is returned once and the other conns keep
+// reading; once every conn has failed, ReadFrom returns the last error
+func (mc *MultiPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	select {
+	case <-mc.closeCh:
+		return 0, nil, mc.opError("read", net.ErrClosed)
+	default:
+	}
+	select {
+	case pkt := <-mc.readCh:
+		return copy(p, pkt.data), pkt.addr, pkt.err
+	case <-mc.doneCh:
+		return 0, nil, mc.doneErr
+	case <-mc.rdeadline.wait():
+		return 0, nil, mc.opError("read", os.ErrDeadlineExceeded)
+	case <-mc.closeCh:
+		return 0, nil, mc.opError("read", net.ErrClosed)
+	}
+}
+
+// WriteTo sends p through the conn matching addr's family: ipv4 (including
+// ipv4-mapped ipv6) destinations use an ipv4 socket, ipv6 an ipv6 socket.
+// Without an ipv4 socket, ipv4 destinations use a dual-stack "[::]" socket
+func (mc *MultiPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	pc := mc.connFor(addr)
+	if pc == nil {
+		return 0, &net.OpError{Op: "write", Net: mc.Network(), Source: mc.LocalAddr(), Addr: addr, Err: ErrNoConnForAddr}
+	}
+	return pc.WriteTo(p, addr)
+}
+
+// connFor returns the first conn with the same network and family as addr,
+// or for ipv4 a conn on the ipv6 wildcard, which also sends ipv4 unless it
+// was opened as "udp6"
+func (mc *MultiPacketConn) connFor(addr net.Addr) net.PacketConn {
+	want, ok := udpFamily(addr)
+	var dual net.PacketConn
+	for _, pc := range mc.conns {
+		if !ok {
+			// not ip, e.g. unixgram: match on network only
+			if pc.LocalAddr().Network() == addr.Network() {
+				return pc
+			}
+			continue
+		}
+		have, ok := udpFamily(pc.LocalAddr())
+		if !ok {
+			continue
+		}
+		if have == want {
+			return pc
+		}
+		if want && dual == nil && isIPv6Wildcard(pc.LocalAddr()) {
+			dual = pc
+		}
+	}
+	return dual
+}
+
+// isIPv6Wildcard reports whether addr is the udp address [::]:port
+func isIPv6Wildcard(addr net.Addr) bool {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return false
+	}
+	ip := udpAddr.AddrPort().Addr()
+	return ip.Is6() && !ip.Is4In6() && ip.IsUnspecified()
+}
+
+// udpFamily returns true for ipv4 addresses, false for ipv6.  ok is false
+// for non-udp addresses
+func udpFamily(addr net.Addr) (is4 bool, ok bool) {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return false, false
+	}
+	ip := udpAddr.AddrPort().Addr()
+	return ip.Is4() || ip.Is4In6(), true
+}
+
+// Close closes all conns.  Like MultiListener.Close it is idempotent
+func (mc *MultiPacketConn) Close() error {
+	err := mc.opError("close", net.ErrClosed)
+	mc.closeOnce.Do(func() {
+		close(mc.closeCh)
+		err = nil
+		for _, pc := range mc.conns {
+			if cerr := pc.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+		mc.wg.Wait()
+	})
+	return err
+}
+
+// LocalAddr returns the preferred (first) conn address
+func (mc *MultiPacketConn) LocalAddr() net.Addr {
+	return mc.conns[0].LocalAddr()
+}
+
+// AllAddr returns all the addresses, comma-separated
+//
+// NOTE: NOT A VALID IP ADDRESS . Use LocalAddr() for a valid address
+func (mc *MultiPacketConn) AllAddr() net.Addr {
+	return mc
+}
+
+// Network() implementation for net.Addr
+func (mc *MultiPacketConn) Network() string {
+	return "udp+multi"
+}
+
+// String() joins all addresses, comma separated, for logs & debug
+func (mc *MultiPacketConn) String() string {
+	addrs := make([]string, len(mc.conns))
+	for i, pc := range mc.conns {
+		addrs[i] = pc.LocalAddr().String()
+	}
+	return strings.Join(addrs, ",")
+}
+
+// SetDeadline sets the read and write deadlines
+func (mc *MultiPacketConn) SetDeadline(t time.Time) error {
+	if err := mc.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return mc.SetWriteDeadline(t)
+}
+
+// SetReadDeadline makes ReadFrom return a timeout error once t passes
+func (mc *MultiPacketConn) SetReadDeadline(t time.Time) error {
+	if isClosedChan(mc.closeCh) {
+		return mc.opError("set", net.ErrClosed)
+	}
+	mc.rdeadline.set(t)
+	return nil
+}
+
+// SetWriteDeadline sets the write deadline on every conn
+func (mc *MultiPacketConn) SetWriteDeadline(t time.Time) error {
+	for _, pc := range mc.conns {
+		if err := pc.SetWriteDeadline(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mc *MultiPacketConn) opError(op string, err error) error {
+	return &net.OpError{Op: op, Net: mc.Network(), Source: mc, Err: err}
+}
@@ -0,0 +1,181 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMultiPacketConn(t *testing.T) {
+	mc, err := NewLocalLoopbackUDP("0")
+	if err != nil {
+		t.Fatalf("Failed to create MultiPacketConn: %v", err)
+	}
+	defer mc.Close()
+	t.Logf("MultiPacketConn serving on: %s", mc.AllAddr())
+
+	port := mc.LocalAddr().(*net.UDPAddr).Port
+	for _, pc := range mc.conns {
+		if p := pc.LocalAddr().(*net.UDPAddr).Port; p != port {
+			t.Errorf("Expected port %d on %s, got %d", port, pc.LocalAddr(), p)
+		}
+	}
+
+	for _, pc := range mc.conns {
+		serverAddr := pc.LocalAddr().String()
+		t.Run(serverAddr, func(t *testing.T) {
+			client, err := net.Dial("udp", serverAddr)
+			if err != nil {
+				t.Fatalf("Failed to dial %s: %v", serverAddr, err)
+			}
+			defer client.Close()
+			if _, err := client.Write([]byte("ping")); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+
+			if err := mc.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+				t.Fatalf("SetReadDeadline: %v", err)
+			}
+			buf := make([]byte, 16)
+			n, addr, err := mc.ReadFrom(buf)
+			if err != nil {
+				t.Fatalf("ReadFrom: %v", err)
+			}
+			if string(buf[:n]) != "ping" {
+				t.Errorf("Expected ping, got %q", buf[:n])
+			}
+			if addr.String() != client.LocalAddr().String() {
+				t.Errorf("Expected packet from %s, got %s", client.LocalAddr(), addr)
+			}
+
+			// the reply goes out through the socket of the same family
+			if _, err := mc.WriteTo([]byte("pong"), addr); err != nil {
+				t.Fatalf("WriteTo %s: %v", addr, err)
+			}
+			if err := client.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+				t.Fatalf("SetReadDeadline: %v", err)
+			}
+			n, err = client.Read(buf)
+			if err != nil {
+				t.Fatalf("client Read: %v", err)
+			}
+			if string(buf[:n]) != "pong" {
+				t.Errorf("Expected pong, got %q", buf[:n])
+			}
+		})
+	}
+}
+
+func TestMultiPacketConnDeadline(t *testing.T) {
+	mc, err := NewLocalLoopbackUDP("0")
+	if err != nil {
+		t.Fatalf("Failed to create MultiPacketConn: %v", err)
+	}
+	defer mc.Close()
+	if err := mc.SetReadDeadline(time.Now().Add(20 * time.Millisecond)); err != nil {
+		t.Fatalf("SetReadDeadline: %v", err)
+	}
+	_, _, err = mc.ReadFrom(make([]byte, 16))
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatalf("Expected timeout error, got %v", err)
+	}
+}
+
+func TestMultiPacketConnNoFamily(t *testing.T) {
+	mc, err := NewMultiPacketConn(Addresses{"[::1]:0"})
+	if err != nil {
+		t.Fatalf("Failed to create MultiPacketConn: %v", err)
+	}
+	defer mc.Close()
+	_, err = mc.WriteTo([]byte("ping"), &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 9})
+	if !errors.Is(err, ErrNoConnForAddr) {
+		t.Errorf("Expected ErrNoConnForAddr, got %v", err)
+	}
+}
+
+func TestMultiPacketConnClose(t *testing.T) {
+	mc, err := NewLocalLoopbackUDP("0")
+	if err != nil {
+		t.Fatalf("Failed to create MultiPacketConn: %v", err)
+	}
+	if err := mc.Close(); err != nil {
+		t.Fatalf("First close failed unexpectedly: %v", err)
+	}
+	if err := mc.Close(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Second close should return net.ErrClosed, got %v", err)
+	}
+	if _, _, err := mc.ReadFrom(make([]byte, 16)); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("ReadFrom after close should return net.ErrClosed, got %v", err)
+	}
+}
+
+// TestMultiPacketConnDualStack ipv4 destinations are sent through a [::]
+// socket when there is no ipv4 one
+func TestMultiPacketConnDualStack(t *testing.T) {
+	mc, err := NewMultiPacketConn(Addresses{"[::]:0"})
+	if err != nil {
+		t.Fatalf("Failed to create MultiPacketConn: %v", err)
+	}
+	defer mc.Close()
+	peer, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer peer.Close()
+	if _, err := mc.WriteTo([]byte("ping"), peer.LocalAddr()); err != nil {
+		t.Fatalf("WriteTo %s: %v", peer.LocalAddr(), err)
+	}
+	if err := peer.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatalf("SetReadDeadline: %v", err)
+	}
+	buf := make([]byte, 16)
+	n, _, err := peer.ReadFrom(buf)
+	if err != nil || string(buf[:n]) != "ping" {
+		t.Errorf("Expected ping, got %q %v", buf[:n], err)
+	}
+}
+
+// errPacketConn fails every ReadFrom with err
+type errPacketConn struct {
+	net.PacketConn
+	err error
+}
+
+func (c *errPacketConn) ReadFrom([]byte) (int, net.Addr, error) {
+	return 0, nil, c.err
+}
+
+func (c *errPacketConn) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.ParseIP("::1"), Port: 53}
+}
+
+func (c *errPacketConn) Close() error {
+	return nil
+}
+
+func TestMultiPacketConnAllConnsFailed(t *testing.T) {
+	broken := errors.New("conn broken")
+	mc, err := NewMultiPacketConnRaw([]net.PacketConn{&errPacketConn{err: broken}, &errPacketConn{err: broken}})
+	if err != nil {
+		t.Fatalf("NewMultiPacketConnRaw: %v", err)
+	}
+	defer mc.Close()
+	// each conn's error is returned once, then the last one for good
+	for i := 0; i < 4; i++ {
+		done := make(chan error, 1)
+		go func() {
+			_, _, err := mc.ReadFrom(make([]byte, 16))
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if !errors.Is(err, broken) {
+				t.Fatalf("ReadFrom %d: expected broken conn error, got %v", i, err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("ReadFrom %d blocked after every conn failed", i)
+		}
+	}
+}